@@ -0,0 +1,76 @@
+// Package orchid
+//Copyright (c) 2022 Epiphyte LLC. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+// Author: Fernandez-Alcon, Jose
+// e-mail: jose@epiphyte.io
+package orchid
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+//Describes the state of the adaptive level, which suppresses low levels while the logging rate is over budget
+type adaptiveLevel struct {
+	mu          sync.Mutex
+	budget      int       //Messages per second allowed before suppressing, zero when disabled
+	windowStart time.Time //Start of the current one second window
+	count       int       //Messages logged in the current window
+	engaged     bool      //Whether levels below WARN are being suppressed
+}
+
+//Counts a message logged at t and reports whether it should be written.
+//The returned notice, if any, must be logged by the caller once the lock is released.
+func (a *adaptiveLevel) allow(severity string, t time.Time) (allowed bool, notice string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.budget <= 0 {
+		return true, ""
+	}
+	if elapsed := t.Sub(a.windowStart); elapsed >= time.Second || elapsed < 0 {
+		if a.engaged && (a.count <= a.budget || elapsed >= 2*time.Second) {
+			a.engaged = false
+			notice = fmt.Sprintf("logging rate back under %d messages/s, restoring all levels", a.budget)
+		}
+		a.windowStart, a.count = t, 0
+	}
+	a.count++
+	if !a.engaged && a.count > a.budget {
+		a.engaged = true
+		notice = fmt.Sprintf("logging rate over %d messages/s, suppressing levels below WARN", a.budget)
+	}
+	if !a.engaged {
+		return true, notice
+	}
+	level, err := ParseLevel(severity)
+	return err != nil || level >= WarnLevel, notice
+}
+
+//Protects the program from logging storms: while more than budgetPerSec messages are logged per second,
+//messages below WARN are dropped. A notice is logged when suppression starts and when it ends.
+//Zero disables it.
+func (c *Configuration) SetAdaptiveLevel(budgetPerSec int) {
+	a := &c.adaptive
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.budget = budgetPerSec
+	a.windowStart, a.count, a.engaged = time.Time{}, 0, false
+}
+
+func (c *Configuration) GetAdaptiveLevel() int {
+	a := &c.adaptive
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.budget
+}
+
+//Reports whether the message passes the adaptive level, logging the notices it produces
+func (c *Configuration) adaptiveAllows(l *logMessage) bool {
+	allowed, notice := c.adaptive.allow(l.Severity, l.Time)
+	if notice != "" {
+		c.New("orchid").Warn(notice)
+	}
+	return allowed
+}
@@ -0,0 +1,68 @@
+// Package orchid
+//Copyright (c) 2022 Epiphyte LLC. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+// Author: Fernandez-Alcon, Jose
+// e-mail: jose@epiphyte.io
+package orchid
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAdaptiveLevel(t *testing.T) {
+	config := GetConfiguration()
+	defer config.SetAdaptiveLevel(0)
+	output := captureOutput(t)
+	start := time.Now()
+	send := func(severity string, text string, at time.Duration) {
+		m := logMessage{Severity: severity, Module: "Storm", Text: text, Time: start.Add(at)}
+		m.printLogMessage()
+	}
+
+	config.SetAdaptiveLevel(10)
+	captureConsole(t, func() {
+		for i := 0; i < 100; i++ {
+			send("DEBUG", "burst", time.Duration(i)*time.Millisecond)
+		}
+		send("ERROR", "important", 200*time.Millisecond)
+		send("INFO", "after the storm", 2500*time.Millisecond)
+	})
+
+	out := output.String()
+	if n := strings.Count(out, "burst"); n != 10 {
+		t.Errorf("expected only the budget of 10 DEBUG messages, got %d", n)
+	}
+	if !strings.Contains(out, "important") {
+		t.Errorf("ERROR should not be suppressed, got %q", out)
+	}
+	if !strings.Contains(out, "after the storm") {
+		t.Errorf("INFO should be restored after the storm, got %q", out)
+	}
+	if !strings.Contains(out, "suppressing levels below WARN") || !strings.Contains(out, "restoring all levels") {
+		t.Errorf("expected engage and disengage notices, got %q", out)
+	}
+}
+
+func TestAdaptiveLevelClone(t *testing.T) {
+	global := captureOutput(t)
+	config := GetConfiguration().Clone()
+	var output strings.Builder
+	config.SetOutput(&output)
+	config.SetAdaptiveLevel(2)
+	logger := config.New("Storm")
+
+	captureConsole(t, func() {
+		for i := 0; i < 5; i++ {
+			logger.Debug("burst")
+		}
+	})
+	if !strings.Contains(output.String(), "orchid               WARN   logging rate over 2 messages/s") {
+		t.Errorf("the notice should go to the configuration that changed state, got %q", output.String())
+	}
+	if strings.Contains(global.String(), "logging rate") {
+		t.Errorf("the notice should not reach the global configuration, got %q", global.String())
+	}
+}
@@ -0,0 +1,206 @@
+// Package orchid
+//Copyright (c) 2022 Epiphyte LLC. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+// Author: Fernandez-Alcon, Jose
+// e-mail: jose@epiphyte.io
+package orchid
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+//Write failures waiting to be reported before further ones are printed on stderr
+const asyncFailureQueueSize = 64
+
+//Describes the background goroutine that writes messages to the file when async mode is on
+type asyncWriter struct {
+	config   *Configuration    //The configuration whose batch settings apply
+	queue    chan asyncItem    //Messages waiting to be written, in order
+	done     chan struct{}     //Closed when the goroutine exits
+	failures chan asyncFailure //Write failures for the reporting goroutine, so the error handler never blocks the writer
+	reported chan struct{}     //Closed when the reporting goroutine exits
+}
+
+//Describes a message the async writer failed to write
+type asyncFailure struct {
+	msg logMessage
+	err error
+}
+
+//Describes an entry of the async queue: a message to write, or a marker closed once everything before it was written
+type asyncItem struct {
+	msg     logMessage
+	flushed chan struct{}
+}
+
+func newAsyncWriter(c *Configuration, bufferSize int) *asyncWriter {
+	a := &asyncWriter{
+		config:   c,
+		queue:    make(chan asyncItem, bufferSize),
+		done:     make(chan struct{}),
+		failures: make(chan asyncFailure, asyncFailureQueueSize),
+		reported: make(chan struct{}),
+	}
+	go a.run()
+	go a.report()
+	return a
+}
+
+//Writes the queued messages in batches, once SetBatchFlushSize messages are collected or the batch is
+//SetBatchFlushInterval old, whichever comes first, and right away when neither is set. Flush markers
+//and stopping write the batch too.
+func (a *asyncWriter) run() {
+	defer close(a.done)
+	defer close(a.failures)
+	var batch []logMessage
+	var timer *time.Timer
+	var expired <-chan time.Time
+	flush := func() {
+		for i := range batch {
+			a.write(&batch[i])
+		}
+		batch = batch[:0]
+		if timer != nil {
+			timer.Stop()
+			timer, expired = nil, nil
+		}
+	}
+	for {
+		select {
+		case item, ok := <-a.queue:
+			if !ok {
+				flush()
+				return
+			}
+			if item.flushed != nil {
+				flush()
+				close(item.flushed)
+				continue
+			}
+			batch = append(batch, item.msg)
+			size, interval := a.config.GetBatchFlushSize(), a.config.GetBatchFlushInterval()
+			if (size <= 0 && interval <= 0) || (size > 0 && len(batch) >= size) {
+				flush()
+			} else if interval > 0 && timer == nil {
+				timer = time.NewTimer(interval)
+				expired = timer.C
+			}
+		case <-expired:
+			timer, expired = nil, nil
+			flush()
+		}
+	}
+}
+
+//Writes the message, handing a failure to report instead of the error handler, which may log and so
+//wait on this very queue
+func (a *asyncWriter) write(l *logMessage) {
+	if err := l.writeToFile(); err != nil {
+		select {
+		case a.failures <- asyncFailure{msg: *l, err: err}:
+		default:
+			fmt.Fprintln(os.Stderr, "ORCHID FILE ERROR:", err)
+		}
+	}
+}
+
+//Passes the write failures to the error handler
+func (a *asyncWriter) report() {
+	defer close(a.reported)
+	for f := range a.failures {
+		f.msg.conf().reportWriteError(&f.msg, f.err)
+	}
+}
+
+//Waits for the failures of a stopped writer to be reported. It must be called without c.asyncMu held,
+//since the error handler may log.
+func (a *asyncWriter) waitReported() {
+	if a != nil {
+		<-a.reported
+	}
+}
+
+//Writes messages to the file or output from a single background goroutine through a queue of
+//bufferSize messages, so callers do not wait on the disk. Logging blocks only when the queue is full.
+//Flush waits for the queue to be written and Close drains it before closing the file.
+//Zero or less writes synchronously again, after draining the queue.
+func (c *Configuration) SetAsync(bufferSize int) {
+	c.asyncMu.Lock()
+	stopped := c.stopAsync()
+	if bufferSize > 0 {
+		c.async = newAsyncWriter(c, bufferSize)
+	}
+	c.asyncMu.Unlock()
+	stopped.waitReported()
+}
+
+//Drains the queue and stops the background goroutine, returning the stopped writer or nil. The caller
+//must hold c.asyncMu, and wait for the writer's failures to be reported once it released it.
+func (c *Configuration) stopAsync() *asyncWriter {
+	stopped := c.async
+	if stopped == nil {
+		return nil
+	}
+	close(stopped.queue)
+	<-stopped.done
+	c.async = nil
+	return stopped
+}
+
+//Writes the message to the file or output, through the queue when async mode is on.
+//Returns the write error in sync mode for the caller to report, queued messages report their own.
+func (c *Configuration) writeMessage(l *logMessage) error {
+	c.asyncMu.RLock()
+	defer c.asyncMu.RUnlock()
+	if c.async != nil {
+		c.async.queue <- asyncItem{msg: *l}
+		return nil
+	}
+	return l.writeToFile()
+}
+
+//Blocks until every message queued so far has been written
+func (c *Configuration) waitAsync() {
+	c.asyncMu.RLock()
+	if c.async == nil {
+		c.asyncMu.RUnlock()
+		return
+	}
+	flushed := make(chan struct{})
+	c.async.queue <- asyncItem{flushed: flushed}
+	c.asyncMu.RUnlock()
+	<-flushed
+}
+
+//Makes the async writer collect n messages before writing them, so heavy logging is written in
+//batches. Combined with SetBatchFlushInterval, a batch is written when either limit is reached. With
+//only a size, an incomplete batch waits for Flush, Sync or Close. Zero or less, the default, removes
+//the limit.
+func (c *Configuration) SetBatchFlushSize(n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.batchSize = n
+}
+
+func (c *Configuration) GetBatchFlushSize() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.batchSize
+}
+
+//Makes the async writer write a batch at most d after its first message was queued, so light logging
+//is not held back waiting for SetBatchFlushSize messages. Zero or less, the default, removes the limit.
+func (c *Configuration) SetBatchFlushInterval(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.batchInterval = d
+}
+
+func (c *Configuration) GetBatchFlushInterval() time.Duration {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.batchInterval
+}
@@ -0,0 +1,191 @@
+// Package orchid
+//Copyright (c) 2022 Epiphyte LLC. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+// Author: Fernandez-Alcon, Jose
+// e-mail: jose@epiphyte.io
+package orchid
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestAsync(t *testing.T) {
+	config := GetConfiguration()
+	defer config.SetAsync(0)
+	output := captureOutput(t)
+	logger := New("Async")
+
+	config.SetAsync(16)
+	var wg sync.WaitGroup
+	captureConsole(t, func() {
+		for g := 0; g < 10; g++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := 0; i < 10; i++ {
+					logger.Info("async message")
+				}
+			}()
+		}
+		wg.Wait()
+	})
+	if err := Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(output.String(), "async message"); n != 100 {
+		t.Errorf("expected 100 messages after Flush, got %d", n)
+	}
+}
+
+func TestAsyncClose(t *testing.T) {
+	config := GetConfiguration()
+	defer config.Close()
+	path := filepath.Join(t.TempDir(), "async.log")
+	if err := config.SetLogFile(path); err != nil {
+		t.Fatal(err)
+	}
+	logger := New("Async")
+
+	config.SetAsync(1000)
+	captureConsole(t, func() {
+		for i := 0; i < 500; i++ {
+			logger.Debug("queued")
+		}
+	})
+	if err := config.Close(); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(string(data), "queued"); n != 500 {
+		t.Errorf("Close should drain the queue, got %d of 500 messages", n)
+	}
+
+	captureConsole(t, func() { logger.Info("after close") })
+	if err := Flush(); err != nil {
+		t.Errorf("Flush after Close should not block or fail, got %v", err)
+	}
+}
+
+func TestAsyncErrorHandlerLogs(t *testing.T) {
+	config := GetConfiguration().Clone()
+	config.SetConsoleEnabled(false)
+	stderr := os.Stderr
+	defer func() { os.Stderr = stderr }()
+	devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer devNull.Close()
+	os.Stderr = devNull
+
+	logger := config.New("Async")
+	var mu sync.Mutex
+	handled := 0
+	config.SetErrorHandler(func(err error) {
+		mu.Lock()
+		handled++
+		mu.Unlock()
+		for i := 0; i < 4; i++ {
+			logger.Warn("sink failed: ", err)
+		}
+	})
+	config.SetOutput(failingWriter{})
+	config.SetAsync(1)
+
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for i := 0; i < 20; i++ {
+			logger.Info("lost")
+		}
+		config.Close()
+	}()
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("a handler logging into the full async queue should not deadlock the writer")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if handled == 0 {
+		t.Error("expected the failures to reach the handler")
+	}
+}
+
+//A buffer the async goroutine can write while the test reads it
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf strings.Builder
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestAsyncBatchFlushSize(t *testing.T) {
+	config := GetConfiguration().Clone()
+	config.SetConsoleEnabled(false)
+	var output lockedBuffer
+	config.SetOutput(&output)
+	config.SetBatchFlushSize(5)
+	config.SetBatchFlushInterval(time.Hour)
+	config.SetAsync(16)
+	defer config.Close()
+	logger := config.New("Async")
+
+	for i := 0; i < 4; i++ {
+		logger.Info("batched")
+	}
+	time.Sleep(50 * time.Millisecond)
+	if n := strings.Count(output.String(), "batched"); n != 0 {
+		t.Fatalf("an incomplete batch should wait, got %d messages", n)
+	}
+	logger.Info("batched")
+	deadline := time.Now().Add(2 * time.Second)
+	for strings.Count(output.String(), "batched") != 5 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if n := strings.Count(output.String(), "batched"); n != 5 {
+		t.Errorf("a full batch should be written, got %d of 5 messages", n)
+	}
+}
+
+func TestAsyncBatchFlushInterval(t *testing.T) {
+	config := GetConfiguration().Clone()
+	config.SetConsoleEnabled(false)
+	var output lockedBuffer
+	config.SetOutput(&output)
+	config.SetBatchFlushInterval(50 * time.Millisecond)
+	config.SetAsync(16)
+	defer config.Close()
+	logger := config.New("Async")
+
+	logger.Info("idle")
+	if strings.Contains(output.String(), "idle") {
+		t.Fatal("the message should wait for the interval")
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for !strings.Contains(output.String(), "idle") && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if !strings.Contains(output.String(), "idle") {
+		t.Error("the batch should be written once the interval expires")
+	}
+}
@@ -0,0 +1,63 @@
+// Package orchid
+//Copyright (c) 2022 Epiphyte LLC. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+// Author: Fernandez-Alcon, Jose
+// e-mail: jose@epiphyte.io
+package orchid
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+//Describes the writer receiving an independent copy of every record
+type auditMirror struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+//Sends a copy of every record to w for auditing, in a fixed format that other settings do not change:
+//
+//	2024-01-02T15:04:05.123456789Z INFO Module text key=value
+//
+//The time is always RFC 3339 in UTC, line breaks in the text are always escaped as \n and \r, and
+//settings such as SetModuleAsField, SetIncludeCaller, record ids and uptime do not add to the line.
+//Stack frames added by ErrorStack are left out. Records below the minimum level, muted on the console,
+//suppressed by the adaptive level or dropped by a hook are mirrored too, as they were before the
+//hooks changed them, and SetLogFile, SetOutput and Close leave the mirror in place. A nil w stops
+//mirroring.
+func (c *Configuration) SetAuditMirror(w io.Writer) {
+	c.audit.mu.Lock()
+	defer c.audit.mu.Unlock()
+	c.audit.w = w
+}
+
+//Writes a redacted copy of the record to the audit mirror, if any, reporting failures to the error handler.
+//It runs before the hooks, which may change or drop l afterwards.
+func (c *Configuration) mirror(l *logMessage) {
+	c.audit.mu.Lock()
+	if c.audit.w == nil {
+		c.audit.mu.Unlock()
+		return
+	}
+	m := *l
+	c.redact(&m)
+	_, err := fmt.Fprintf(c.audit.w, "%s %s %s %s\n", m.Time.UTC().Format(time.RFC3339Nano), m.Severity, fieldValue(m.Module), m.auditText())
+	c.audit.mu.Unlock()
+	if err != nil {
+		c.reportError("ORCHID AUDIT ERROR:", err)
+	}
+}
+
+//Returns the text and key=value fields of the audit line, always on a single line and regardless of
+//the settings that change text lines
+func (l *logMessage) auditText() string {
+	text := newlineEscaper.Replace(l.fullText())
+	for _, f := range l.Fields {
+		text += " " + newlineEscaper.Replace(f.Key) + "=" + fieldValue(fmt.Sprint(f.Value))
+	}
+	return text
+}
@@ -0,0 +1,100 @@
+// Package orchid
+//Copyright (c) 2022 Epiphyte LLC. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+// Author: Fernandez-Alcon, Jose
+// e-mail: jose@epiphyte.io
+package orchid
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAuditMirror(t *testing.T) {
+	config := GetConfiguration()
+	defer config.SetAuditMirror(nil)
+	defer config.SetLevel(config.GetLevel())
+	var audit bytes.Buffer
+	config.SetAuditMirror(&audit)
+
+	if err := config.SetLogFile(filepath.Join(t.TempDir(), "app.log")); err != nil {
+		t.Fatal(err)
+	}
+	if err := config.SetLogFile(""); err != nil {
+		t.Fatal(err)
+	}
+	config.SetLevel(ErrorLevel)
+	logger := New("Audited").With("user", "alice")
+	captureConsole(t, func() {
+		logger.Info("below the minimum level")
+		logger.Error("written everywhere")
+	})
+
+	lines := strings.Split(strings.TrimSuffix(audit.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected both records in the mirror, got %q", audit.String())
+	}
+	if !strings.HasSuffix(lines[0], "Z INFO Audited below the minimum level user=alice") {
+		t.Errorf("unexpected audit line %q", lines[0])
+	}
+	if !strings.HasSuffix(lines[1], "Z ERROR Audited written everywhere user=alice") {
+		t.Errorf("unexpected audit line %q", lines[1])
+	}
+}
+
+func TestAuditMirrorFixedFormat(t *testing.T) {
+	config := GetConfiguration().Clone()
+	config.SetConsoleEnabled(false)
+	var audit bytes.Buffer
+	config.SetAuditMirror(&audit)
+	config.SetEscapeNewlines(false)
+	config.SetModuleAsField(true)
+	config.SetIncludeCaller(true)
+	config.SetIncludeRecordID(true)
+	config.SetIncludeUptime(true)
+
+	logger := config.New("Audited").With("user", "alice")
+	logger.Info("forged\nERROR fake line")
+	logger.Dump("payload", []byte("hi"))
+	lines := strings.Split(strings.TrimSuffix(audit.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected one line per record, got %q", audit.String())
+	}
+	if !strings.HasSuffix(lines[0], `Z INFO Audited forged\nERROR fake line user=alice`) {
+		t.Errorf("other settings should not change the audit line, got %q", lines[0])
+	}
+	if !strings.Contains(lines[1], `Z DEBUG Audited payload (2 bytes)\n00000000`) {
+		t.Errorf("the dump should be escaped onto the audit line, got %q", lines[1])
+	}
+}
+
+func TestAuditMirrorHooks(t *testing.T) {
+	config := GetConfiguration()
+	defer config.SetAuditMirror(nil)
+	defer config.ClearHooks()
+	var audit bytes.Buffer
+	config.SetAuditMirror(&audit)
+	output := captureOutput(t)
+	config.AddHook(func(e *Entry) bool {
+		e.Fields = append(e.Fields, String("host", "web-1"))
+		return !strings.HasPrefix(e.Text, "healthcheck")
+	})
+
+	logger := New("Audited")
+	captureConsole(t, func() {
+		logger.Info("healthcheck ok")
+		logger.Info("request served")
+	})
+	if strings.Contains(output.String(), "healthcheck") {
+		t.Errorf("the hook should drop the message from the output, got %q", output.String())
+	}
+	if !strings.Contains(audit.String(), "Z INFO Audited healthcheck ok\n") {
+		t.Errorf("a message dropped by a hook should still be mirrored, got %q", audit.String())
+	}
+	if !strings.Contains(audit.String(), "Z INFO Audited request served\n") {
+		t.Errorf("the mirror should get the message as it was before the hooks, got %q", audit.String())
+	}
+}
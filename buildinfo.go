@@ -0,0 +1,75 @@
+// Package orchid
+//Copyright (c) 2022 Epiphyte LLC. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+// Author: Fernandez-Alcon, Jose
+// e-mail: jose@epiphyte.io
+package orchid
+
+import "runtime/debug"
+
+//Returns the main module version and VCS revision stamped into the binary by the go command
+func readBuildInfo() (version string, commit string) {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "", ""
+	}
+	version = info.Main.Version
+	for _, setting := range info.Settings {
+		if setting.Key == "vcs.revision" {
+			commit = setting.Value
+		}
+	}
+	return version, commit
+}
+
+//Sets the version and commit reported by SetIncludeBuildInfo, overriding the ones read from the binary
+func (c *Configuration) SetBuildInfo(version, commit string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.buildVersion, c.buildCommit = version, commit
+}
+
+func (c *Configuration) GetBuildInfo() (version string, commit string) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.buildVersion, c.buildCommit
+}
+
+//When enabled every message carries version and commit fields. Unless SetBuildInfo was called they are
+//read from the binary with debug.ReadBuildInfo, which requires building with module and VCS stamping.
+func (c *Configuration) SetIncludeBuildInfo(include bool) {
+	var version, commit string
+	if include {
+		version, commit = readBuildInfo()
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.includeBuildInfo = include
+	if include && c.buildVersion == "" && c.buildCommit == "" {
+		c.buildVersion, c.buildCommit = version, commit
+	}
+}
+
+func (c *Configuration) GetIncludeBuildInfo() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.includeBuildInfo
+}
+
+//Returns the build fields added to every message, if enabled
+func (c *Configuration) buildFields() []Field {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if !c.includeBuildInfo {
+		return nil
+	}
+	var fields []Field
+	if c.buildVersion != "" {
+		fields = append(fields, Field{Key: "version", Value: c.buildVersion})
+	}
+	if c.buildCommit != "" {
+		fields = append(fields, Field{Key: "commit", Value: c.buildCommit})
+	}
+	return fields
+}
@@ -0,0 +1,54 @@
+// Package orchid
+//Copyright (c) 2022 Epiphyte LLC. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+// Author: Fernandez-Alcon, Jose
+// e-mail: jose@epiphyte.io
+package orchid
+
+import (
+	"runtime/debug"
+	"strings"
+	"testing"
+)
+
+func TestSetBuildInfo(t *testing.T) {
+	config := GetConfiguration()
+	defer config.SetBuildInfo("", "")
+	defer config.SetIncludeBuildInfo(false)
+	logger := New("Build")
+
+	config.SetBuildInfo("v1.2.3", "abc123")
+	out := captureConsole(t, func() { logger.Info("release") })
+	if strings.Contains(out, "version=") {
+		t.Errorf("build info should be off by default, got %q", out)
+	}
+
+	config.SetIncludeBuildInfo(true)
+	out = captureConsole(t, func() { logger.With("user", "jose").Info("release") })
+	if !strings.HasSuffix(out, "release user=jose version=v1.2.3 commit=abc123\n") {
+		t.Errorf("expected the build fields, got %q", out)
+	}
+}
+
+func TestReadBuildInfo(t *testing.T) {
+	config := GetConfiguration()
+	defer config.SetBuildInfo("", "")
+	defer config.SetIncludeBuildInfo(false)
+
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		t.Skip("binary built without module support")
+	}
+	config.SetBuildInfo("", "")
+	config.SetIncludeBuildInfo(true)
+	version, commit := config.GetBuildInfo()
+	if version != info.Main.Version {
+		t.Errorf("expected version %q from the build info, got %q", info.Main.Version, version)
+	}
+	for _, setting := range info.Settings {
+		if setting.Key == "vcs.revision" && setting.Value != commit {
+			t.Errorf("expected commit %q from the build info, got %q", setting.Value, commit)
+		}
+	}
+}
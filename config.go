@@ -0,0 +1,805 @@
+// Package orchid
+//Copyright (c) 2022 Epiphyte LLC. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+// Author: Fernandez-Alcon, Jose
+// e-mail: jose@epiphyte.io
+package orchid
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+//Describes the settings shared by every log call
+type Configuration struct {
+	mu        sync.RWMutex
+	disabled  atomic.Bool //Turns every log call into a no-op, set with SetEnabled(false)
+	level     Level       //Messages below this level are discarded
+	colorMode ColorMode   //Whether the console output is colored
+	theme     Theme       //The console colors of each severity
+	style     ColorStyle  //How the theme colors are applied to the console metadata
+
+	includeCaller     bool   //Whether messages carry the file and line of the call
+	includeCallerFunc bool   //Whether messages carry the calling function name
+	consoleFlags      int    //The log package flags used for the console prefix, negative to inherit them
+	consoleTimestamp  bool   //Whether console lines carry a timestamp
+	consoleStdout     bool   //Whether the console is os.Stdout instead of the standard logger's writer
+	splitStreams      bool   //Whether messages below WARN go to os.Stdout and the rest to os.Stderr
+	consoleFormat     Format //How console lines are rendered
+	schemaVersion     int    //The version stamped on JSON records, none when zero
+	stringifyInts     bool   //Whether JSON records write integers beyond 2^53 as strings
+	useUTC            bool   //Whether timestamps are in UTC instead of local time
+	prettyErrors      bool   //Whether ERROR and FATAL stacks and error chains are rendered as a block on the console
+	stackDepth        int    //The most frames ErrorStack logs
+	escapeNewlines    bool   //Whether line breaks in message texts are written as \n and \r
+	dumpLimit         int    //The most bytes Dump shows, unlimited when zero
+
+	jsonNames  map[string]string //Replace the time, severity, module and text keys of JSON records
+	jsonIndent string            //Indents the JSON records of files, single-line when empty
+
+	moduleSeparator  string //Separates the segments of hierarchical module names such as db.pool.conn
+	shortModuleNames bool   //Whether the console shows only the last module segment
+	moduleAsField    bool   //Whether text output also carries the module as module=...
+	moduleWidth      int    //The console column the module name is padded to, none when zero
+	severityWidth    int    //The console column the severity is padded to, none when zero
+
+	assertLevel Level //The level failed assertions are logged at
+	printLevel  Level //The level Printf and Println log at
+	strictInit  bool  //Whether Init fails on an already initialized logger
+
+	consoleMuted    map[string]bool //Severities that are not printed on the console
+	consoleDisabled bool            //Whether nothing is printed on the console, set with SetConsoleEnabled(false)
+	exitOnError     bool            //Whether ERROR messages terminate the program like FATAL
+	exitFunc        func(int)       //Terminates the program after a FATAL message, os.Exit when nil
+	errorHandler    func(error)     //Receives write failures, which are printed on stderr when nil
+	handlers        sync.Map        //The ids of the goroutines running errorHandler, whose own failures go to stderr
+	handlerCalls    atomic.Int32    //The errorHandler calls in progress, so messages skip the goroutine lookup when zero
+
+	includeRecordID   bool          //Whether every message is stamped with an id
+	recordIDGenerator func() string //Generates the ids, a counter when nil
+
+	progressRedraw func() //Repaints a progress bar after each console line, when set
+	separatorChar  rune   //The character separator lines are drawn with, '-' when zero
+	includeUptime  bool   //Whether messages carry the time elapsed since the logger started
+
+	includeBuildInfo bool   //Whether messages carry the version and commit fields
+	buildVersion     string //The version reported in the version field
+	buildCommit      string //The VCS revision reported in the commit field
+	includeHostPID   bool   //Whether messages carry the host and pid fields
+	hostname         string //The host reported in the host field, read when enabled
+
+	consoleBuffer *bufferedConsole //Buffers console output, when enabled
+
+	output           io.Writer      //Receives every message as a plain text line, when set
+	outputMu         sync.Mutex     //Serializes writes to output and guards logFile
+	groupMu          sync.Mutex     //Held while a message or a whole Group is written, keeping group lines together
+	logFile          *logFile       //The file messages are written to when no output is set
+	fileSinks        []*logFile     //Additional files written with their own minimum level
+	syslog           *syslogSink    //Receives every message with a matching priority, when set
+	rotationInterval time.Duration  //Starts a new log file every interval, when positive
+	createDirs       bool           //Whether opening a log file creates its missing directories
+	compressRotated  bool           //Whether files replaced by rotation are compressed with gzip
+	compressing      sync.WaitGroup //Tracks the compressions in progress
+	timeFormat       string         //The time layout of file lines, DefaultTimeFormat when empty
+	timeCache        timestampCache //The last file timestamp, guarded by outputMu
+	async            *asyncWriter   //Writes messages in the background, when enabled
+	asyncMu          sync.RWMutex   //Guards async, held for reading while queueing
+	batchSize        int            //Queued messages the async writer collects before writing them, zero for no limit
+	batchInterval    time.Duration  //How long the async writer holds a batch before writing it, zero for no limit
+
+	contextExtractors []func(context.Context) []interface{} //Pull message fields from the context of InfoContext and its peers
+	redactors         []redactor                            //Replace sensitive text in every message, in order
+	hooks             []func(*Entry) bool                   //Change or drop every message before it is written, in order
+
+	subscribers subscribers   //Channels receiving a copy of every record
+	adaptive    adaptiveLevel //Suppresses low levels during logging storms
+	sampler     sampler       //Drops repeats of identical messages, when enabled
+	audit       auditMirror   //Receives a copy of every record regardless of the other settings
+	memory      memoryBuffer  //Keeps the most recent messages for crash dumps, when enabled
+	stats       stats         //Counts the written messages per severity
+	webhook     webhook       //Receives alerts at or above its level, when set
+}
+
+var configuration = &Configuration{
+	level:            DebugLevel,
+	assertLevel:      ErrorLevel,
+	printLevel:       InfoLevel,
+	consoleFlags:     -1,
+	consoleTimestamp: true,
+	colorMode:        colorModeFromEnv(),
+	theme:            DefaultTheme(),
+	moduleSeparator:  ".",
+	moduleWidth:      DefaultModuleWidth,
+	severityWidth:    DefaultSeverityWidth,
+	escapeNewlines:   true,
+	dumpLimit:        DefaultDumpLimit,
+	stackDepth:       DefaultStackDepth,
+}
+
+func GetConfiguration() *Configuration {
+	return configuration
+}
+
+//Returns a copy of the settings, for loggers that must not share the global ones, e.g. in parallel tests:
+//
+//	config := orchid.GetConfiguration().Clone()
+//	config.SetLevel(orchid.WarnLevel)
+//	logger := config.New("worker")
+//
+//The copy starts without the log files, syslog connection, console buffer, async writer, subscribers,
+//in-memory message buffer and webhook of c, and with fresh sampling, adaptive level and stats state. The output writer, audit mirror, handlers,
+//extractors and redactors are shared with c.
+func (c *Configuration) Clone() *Configuration {
+	c.mu.RLock()
+	clone := &Configuration{
+		level:             c.level,
+		colorMode:         c.colorMode,
+		theme:             Theme{Colors: make(map[string]string, len(c.theme.Colors))},
+		style:             c.style,
+		includeCaller:     c.includeCaller,
+		includeCallerFunc: c.includeCallerFunc,
+		consoleFlags:      c.consoleFlags,
+		consoleTimestamp:  c.consoleTimestamp,
+		consoleStdout:     c.consoleStdout,
+		splitStreams:      c.splitStreams,
+		useUTC:            c.useUTC,
+		prettyErrors:      c.prettyErrors,
+		escapeNewlines:    c.escapeNewlines,
+		dumpLimit:         c.dumpLimit,
+		stackDepth:        c.stackDepth,
+		moduleSeparator:   c.moduleSeparator,
+		shortModuleNames:  c.shortModuleNames,
+		moduleAsField:     c.moduleAsField,
+		moduleWidth:       c.moduleWidth,
+		severityWidth:     c.severityWidth,
+		assertLevel:       c.assertLevel,
+		printLevel:        c.printLevel,
+		strictInit:        c.strictInit,
+		exitOnError:       c.exitOnError,
+		consoleDisabled:   c.consoleDisabled,
+		consoleFormat:     c.consoleFormat,
+		schemaVersion:     c.schemaVersion,
+		stringifyInts:     c.stringifyInts,
+		jsonIndent:        c.jsonIndent,
+		exitFunc:          c.exitFunc,
+		errorHandler:      c.errorHandler,
+		includeRecordID:   c.includeRecordID,
+		recordIDGenerator: c.recordIDGenerator,
+		progressRedraw:    c.progressRedraw,
+		separatorChar:     c.separatorChar,
+		includeUptime:     c.includeUptime,
+		includeBuildInfo:  c.includeBuildInfo,
+		buildVersion:      c.buildVersion,
+		buildCommit:       c.buildCommit,
+		includeHostPID:    c.includeHostPID,
+		hostname:          c.hostname,
+		output:            c.output,
+		rotationInterval:  c.rotationInterval,
+		createDirs:        c.createDirs,
+		compressRotated:   c.compressRotated,
+		timeFormat:        c.timeFormat,
+		batchSize:         c.batchSize,
+		batchInterval:     c.batchInterval,
+		contextExtractors: append([]func(context.Context) []interface{}(nil), c.contextExtractors...),
+		redactors:         append([]redactor(nil), c.redactors...),
+		hooks:             append([]func(*Entry) bool(nil), c.hooks...),
+	}
+	for severity, color := range c.theme.Colors {
+		clone.theme.Colors[severity] = color
+	}
+	if c.jsonNames != nil {
+		clone.jsonNames = make(map[string]string, len(c.jsonNames))
+		for key, name := range c.jsonNames {
+			clone.jsonNames[key] = name
+		}
+	}
+	if c.consoleMuted != nil {
+		clone.consoleMuted = make(map[string]bool, len(c.consoleMuted))
+		for severity, muted := range c.consoleMuted {
+			clone.consoleMuted[severity] = muted
+		}
+	}
+	c.mu.RUnlock()
+	clone.disabled.Store(c.disabled.Load())
+	clone.SetAdaptiveLevel(c.GetAdaptiveLevel())
+	clone.SetSampling(c.GetSampling())
+	c.audit.mu.Lock()
+	clone.audit.w = c.audit.w
+	c.audit.mu.Unlock()
+	return clone
+}
+
+//Returns a logger for module_name that follows c instead of the global configuration
+func (c *Configuration) New(module_name string) *Logger {
+	return &Logger{module: module_name, initialized: true, config: c, file: &loggerFile{}}
+}
+
+func (c *Configuration) SetLevel(level Level) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.level = level
+}
+
+func (c *Configuration) GetLevel() Level {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.level
+}
+
+//Turns logging on or off globally. While disabled every log call, FATAL included, returns before
+//formatting anything: nothing is written, published or mirrored and the program does not exit.
+//This is meant for benchmarks that must not pay for logging without changing the call sites.
+func (c *Configuration) SetEnabled(enabled bool) {
+	c.disabled.Store(!enabled)
+}
+
+func (c *Configuration) GetEnabled() bool {
+	return !c.disabled.Load()
+}
+
+//Reports whether a message with the given severity passes the minimum level
+func (c *Configuration) enabled(severity string) bool {
+	level, err := ParseLevel(severity)
+	if err != nil {
+		return true
+	}
+	return level >= c.GetLevel()
+}
+
+//Describes when the console output is colored
+type ColorMode int
+
+const (
+	ColorAuto   ColorMode = iota //Color only when the console is a terminal
+	ColorAlways                  //Always color, e.g. when paging with less -R
+	ColorNever                   //Never color
+)
+
+//Returns the initial color mode from the NO_COLOR and FORCE_COLOR conventions (https://no-color.org).
+//FORCE_COLOR wins when both are set, and SetColorMode overrides either.
+func colorModeFromEnv() ColorMode {
+	if force, ok := os.LookupEnv("FORCE_COLOR"); ok && force != "0" && force != "false" {
+		return ColorAlways
+	}
+	if os.Getenv("NO_COLOR") != "" {
+		return ColorNever
+	}
+	return ColorAuto
+}
+
+func (c *Configuration) SetColorMode(mode ColorMode) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.colorMode = mode
+}
+
+func (c *Configuration) GetColorMode() ColorMode {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.colorMode
+}
+
+//Reports whether output written to w should carry color codes
+func (c *Configuration) useColors(w io.Writer) bool {
+	switch c.GetColorMode() {
+	case ColorAlways:
+		return true
+	case ColorNever:
+		return false
+	}
+	return isTerminal(w)
+}
+
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+//When enabled every message carries the file and line of the logging call, e.g. (main.go:42)
+func (c *Configuration) SetIncludeCaller(include bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.includeCaller = include
+}
+
+func (c *Configuration) GetIncludeCaller() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.includeCaller
+}
+
+func (c *Configuration) SetIncludeCallerFunc(include bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.includeCallerFunc = include
+}
+
+func (c *Configuration) GetIncludeCallerFunc() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.includeCallerFunc
+}
+
+//Sets the log package flags (log.Ldate, log.Ltime, log.Lmicroseconds, log.LUTC...) used to prefix console lines.
+//A negative value uses whatever flags the standard logger has, which is the default.
+func (c *Configuration) SetConsoleFlags(flags int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.consoleFlags = flags
+}
+
+func (c *Configuration) GetConsoleFlags() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.consoleFlags < 0 {
+		return log.Flags()
+	}
+	return c.consoleFlags
+}
+
+//When disabled console lines carry no timestamp, for supervisors such as journald or Docker that add their own.
+//Output set with SetOutput keeps its timestamp.
+func (c *Configuration) SetConsoleTimestamp(enabled bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.consoleTimestamp = enabled
+}
+
+func (c *Configuration) GetConsoleTimestamp() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.consoleTimestamp
+}
+
+//Returns the logger that console lines of the given severity are printed with
+func (c *Configuration) consoleLogger(severity string) *log.Logger {
+	writer, _ := c.consoleFor(severity)
+	c.mu.RLock()
+	direct := c.consoleBuffer == nil && !c.consoleStdout && !c.splitStreams
+	flags, timestamp, utc := c.consoleFlags, c.consoleTimestamp, c.useUTC
+	c.mu.RUnlock()
+	if flags < 0 && direct && timestamp && !utc {
+		return log.Default()
+	}
+	if flags < 0 {
+		flags = log.Flags()
+	}
+	if utc {
+		flags |= log.LUTC
+	}
+	if !timestamp {
+		flags &^= log.Ldate | log.Ltime | log.Lmicroseconds
+	}
+	return log.New(writer, log.Prefix(), flags)
+}
+
+func (c *Configuration) SetModuleSeparator(sep string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.moduleSeparator = sep
+}
+
+func (c *Configuration) GetModuleSeparator() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.moduleSeparator
+}
+
+//When enabled the console shows only the last segment of hierarchical module names (conn for db.pool.conn)
+func (c *Configuration) SetShortModuleNames(short bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.shortModuleNames = short
+}
+
+func (c *Configuration) GetShortModuleNames() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.shortModuleNames
+}
+
+//The console column widths of the module name and severity unless changed
+const (
+	DefaultModuleWidth   = 20
+	DefaultSeverityWidth = 6
+)
+
+//Sets the width the module name is padded to on the console, DefaultModuleWidth by default.
+//Zero means no padding. Longer names are never cut.
+func (c *Configuration) SetModuleWidth(width int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.moduleWidth = max(width, 0)
+}
+
+func (c *Configuration) GetModuleWidth() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.moduleWidth
+}
+
+//Sets the width the severity is padded to on the console, DefaultSeverityWidth by default. Zero means no padding.
+func (c *Configuration) SetSeverityWidth(width int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.severityWidth = max(width, 0)
+}
+
+func (c *Configuration) GetSeverityWidth() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.severityWidth
+}
+
+//Returns the module name as it should appear on the console
+func (c *Configuration) consoleModule(name string) string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if !c.shortModuleNames || c.moduleSeparator == "" {
+		return name
+	}
+	i := strings.LastIndex(name, c.moduleSeparator)
+	if i < 0 {
+		return name
+	}
+	return name[i+len(c.moduleSeparator):]
+}
+
+//Sets the level failed assertions are logged at. Use FatalLevel to stop the program on a failed assertion.
+func (c *Configuration) SetAssertLevel(level Level) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.assertLevel = level
+}
+
+func (c *Configuration) GetAssertLevel() Level {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.assertLevel
+}
+
+//When enabled text output carries the full module as a parseable module=... field after the message
+func (c *Configuration) SetModuleAsField(enabled bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.moduleAsField = enabled
+}
+
+func (c *Configuration) GetModuleAsField() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.moduleAsField
+}
+
+//Sets the level Printf and Println log at
+func (c *Configuration) SetPrintLevel(level Level) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.printLevel = level
+}
+
+func (c *Configuration) GetPrintLevel() Level {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.printLevel
+}
+
+//Stops the given severities from being printed on the console. Calling it with no levels unmutes everything.
+func (c *Configuration) SetConsoleMuteLevels(levels ...string) {
+	muted := make(map[string]bool, len(levels))
+	for _, level := range levels {
+		if lv, err := ParseLevel(level); err == nil {
+			muted[lv.String()] = true
+		}
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.consoleMuted = muted
+}
+
+//Turns the console output on or off, e.g. for daemons that only log to files. While it is off messages
+//are still written to the files and other destinations, and FATAL still exits. On by default.
+func (c *Configuration) SetConsoleEnabled(enabled bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.consoleDisabled = !enabled
+}
+
+func (c *Configuration) GetConsoleEnabled() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return !c.consoleDisabled
+}
+
+func (c *Configuration) consoleMutes(severity string) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.consoleDisabled || c.consoleMuted[severity]
+}
+
+//When enabled logging at ERROR terminates the program after the message is written, like FATAL
+func (c *Configuration) SetExitOnError(enabled bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.exitOnError = enabled
+}
+
+func (c *Configuration) GetExitOnError() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.exitOnError
+}
+
+//When enabled every message carries an id field, a process-wide counter unless SetRecordIDGenerator is used
+func (c *Configuration) SetIncludeRecordID(include bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.includeRecordID = include
+}
+
+func (c *Configuration) GetIncludeRecordID() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.includeRecordID
+}
+
+//Sets the function generating record ids (e.g. a UUID or ULID generator) and enables them.
+//A nil fn restores the built-in counter. fn is called for every message and must be safe for concurrent use.
+func (c *Configuration) SetRecordIDGenerator(fn func() string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.recordIDGenerator = fn
+	c.includeRecordID = true
+}
+
+var recordCounter uint64
+
+//Returns the id for a new message, or an empty string when ids are disabled
+func (c *Configuration) nextRecordID() string {
+	c.mu.RLock()
+	include, generate := c.includeRecordID, c.recordIDGenerator
+	c.mu.RUnlock()
+	if !include {
+		return ""
+	}
+	if generate != nil {
+		return generate()
+	}
+	return strconv.FormatUint(atomic.AddUint64(&recordCounter, 1), 10)
+}
+
+//Makes console logging cooperate with a live progress bar: the current terminal line is cleared
+//before each log line and redraw is called afterwards to repaint the bar. A nil redraw disables it.
+func (c *Configuration) SetProgressRedraw(redraw func()) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.progressRedraw = redraw
+}
+
+func (c *Configuration) getProgressRedraw() func() {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.progressRedraw
+}
+
+//When enabled every message carries an uptime_ms field with the milliseconds elapsed since the logger started
+func (c *Configuration) SetIncludeUptime(include bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.includeUptime = include
+}
+
+func (c *Configuration) GetIncludeUptime() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.includeUptime
+}
+
+//Sets a writer (a file, os.Stdout, a network connection, a bytes.Buffer...) that receives every
+//message as a plain text line in addition to the console. A nil writer disables it.
+//Orchid never closes the writer, the caller owns it.
+func (c *Configuration) SetOutput(w io.Writer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.output = w
+}
+
+func (c *Configuration) GetOutput() io.Writer {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.output
+}
+
+//Sets the function called when writing a message to the output, a log file, syslog or the audit
+//mirror fails, e.g. to route the failures into monitoring. It is called without any orchid lock held,
+//so it may log, but failures of the messages it logs are printed on stderr to avoid a loop. Failures
+//of other goroutines still reach it, so it must be safe for concurrent use.
+//A nil handler restores printing ORCHID FILE ERROR on stderr.
+func (c *Configuration) SetErrorHandler(handler func(error)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.errorHandler = handler
+}
+
+//Passes a write failure to the error handler, or prints it on stderr after prefix. Failures caused
+//by the handler itself go to stderr, while other goroutines keep reaching the handler meanwhile.
+func (c *Configuration) reportError(prefix string, err error) {
+	c.mu.RLock()
+	handler := c.errorHandler
+	c.mu.RUnlock()
+	if handler == nil || c.inErrorHandler() {
+		fmt.Fprintln(os.Stderr, prefix, err)
+		return
+	}
+	id := goroutineID()
+	c.handlers.Store(id, true)
+	c.handlerCalls.Add(1)
+	defer func() {
+		c.handlerCalls.Add(-1)
+		c.handlers.Delete(id)
+	}()
+	handler(err)
+}
+
+//Reports a failure to write l like reportError, on stderr when l was logged by the error handler
+func (c *Configuration) reportWriteError(l *logMessage, err error) {
+	if l.fromHandler {
+		fmt.Fprintln(os.Stderr, "ORCHID FILE ERROR:", err)
+		return
+	}
+	c.reportError("ORCHID FILE ERROR:", err)
+}
+
+//Reports whether the calling goroutine is running the error handler
+func (c *Configuration) inErrorHandler() bool {
+	if c.handlerCalls.Load() == 0 {
+		return false
+	}
+	_, running := c.handlers.Load(goroutineID())
+	return running
+}
+
+//Returns the id of the calling goroutine, read from the "goroutine 42 [running]:" header of its stack
+func goroutineID() uint64 {
+	var buf [64]byte
+	header := strings.TrimPrefix(string(buf[:runtime.Stack(buf[:], false)]), "goroutine ")
+	id, _ := strconv.ParseUint(header[:strings.IndexByte(header, ' ')], 10, 64)
+	return id
+}
+
+//Sets the function called with exit code 1 after a FATAL message is written, os.Exit by default.
+//Tests can inject a function that records the call instead of terminating the test binary.
+//A nil fn restores os.Exit.
+func (c *Configuration) SetExitFunc(fn func(int)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.exitFunc = fn
+}
+
+func (c *Configuration) exit(code int) {
+	c.mu.RLock()
+	fn := c.exitFunc
+	c.mu.RUnlock()
+	if fn == nil {
+		fn = os.Exit
+	}
+	fn(code)
+}
+
+//The most bytes Dump shows unless SetDumpLimit is used
+const DefaultDumpLimit = 4096
+
+//Sets the most bytes of a payload Dump shows, DefaultDumpLimit by default. Zero or less shows everything.
+func (c *Configuration) SetDumpLimit(n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.dumpLimit = n
+}
+
+func (c *Configuration) GetDumpLimit() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.dumpLimit
+}
+
+//Writes line breaks in message texts as the two characters \n and \r on the console and in text lines, so a
+//logged user-supplied string cannot forge extra log lines. On by default. JSON records keep the text as
+//logged, and the blocks added by Stack, StackAll, ErrorStack and Dump keep their lines.
+func (c *Configuration) SetEscapeNewlines(escape bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.escapeNewlines = escape
+}
+
+func (c *Configuration) GetEscapeNewlines() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.escapeNewlines
+}
+
+//When enabled ERROR and FATAL messages show their stack and the chain of every error field as an
+//indented block below the console line. Other destinations keep the single-line form.
+func (c *Configuration) SetPrettyErrors(enabled bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.prettyErrors = enabled
+}
+
+func (c *Configuration) GetPrettyErrors() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.prettyErrors
+}
+
+//The most frames ErrorStack logs unless SetStackDepth is used
+const DefaultStackDepth = 32
+
+//Sets the most frames ErrorStack logs, DefaultStackDepth by default. Values below one log a single frame.
+func (c *Configuration) SetStackDepth(depth int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.stackDepth = max(depth, 1)
+}
+
+func (c *Configuration) GetStackDepth() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.stackDepth
+}
+
+//When enabled Init returns an error instead of renaming a logger that already has a module name,
+//which catches accidental re-initialization. The default is lenient.
+func (c *Configuration) SetStrictInit(strict bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.strictInit = strict
+}
+
+func (c *Configuration) GetStrictInit() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.strictInit
+}
+
+//When enabled every timestamp, on the console, in files and in records, is in UTC instead of local time
+func (c *Configuration) SetUseUTC(utc bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.useUTC = utc
+}
+
+func (c *Configuration) GetUseUTC() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.useUTC
+}
+
+//Returns t in the location messages are stamped in, UTC under SetUseUTC and local time otherwise
+func (c *Configuration) inZone(t time.Time) time.Time {
+	if c.GetUseUTC() {
+		return t.UTC()
+	}
+	return t
+}
+
+//Returns the current time as messages are stamped with it
+func (c *Configuration) now() time.Time {
+	return c.inZone(time.Now())
+}
@@ -0,0 +1,181 @@
+// Package orchid
+//Copyright (c) 2022 Epiphyte LLC. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+// Author: Fernandez-Alcon, Jose
+// e-mail: jose@epiphyte.io
+package orchid
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestColorModeFromEnv(t *testing.T) {
+	tests := []struct {
+		noColor, forceColor string
+		expected            ColorMode
+	}{
+		{"", "", ColorAuto},
+		{"1", "", ColorNever},
+		{"", "1", ColorAlways},
+		{"", "0", ColorAuto},
+		{"1", "false", ColorNever},
+		{"1", "3", ColorAlways},
+	}
+	//t.Setenv restores the original values once the test ends
+	t.Setenv("NO_COLOR", "")
+	t.Setenv("FORCE_COLOR", "")
+	for _, tt := range tests {
+		os.Unsetenv("NO_COLOR")
+		os.Unsetenv("FORCE_COLOR")
+		if tt.noColor != "" {
+			os.Setenv("NO_COLOR", tt.noColor)
+		}
+		if tt.forceColor != "" {
+			os.Setenv("FORCE_COLOR", tt.forceColor)
+		}
+		if mode := colorModeFromEnv(); mode != tt.expected {
+			t.Errorf("NO_COLOR=%q FORCE_COLOR=%q: expected mode %d, got %d", tt.noColor, tt.forceColor, tt.expected, mode)
+		}
+	}
+}
+
+func TestSetEnabled(t *testing.T) {
+	config := GetConfiguration()
+	defer config.SetEnabled(true)
+	output := captureOutput(t)
+	exits := stubExit(t)
+	logger := New("TestEnabled")
+
+	config.SetEnabled(false)
+	console := captureConsole(t, func() {
+		logger.Info("hidden")
+		logger.Printf("hidden %d", 1)
+		logger.Assert(false, "hidden")
+		logger.Stack("ERROR", "hidden")
+		Println("hidden")
+		Fatal("hidden")
+	})
+	if console != "" || output.Len() != 0 {
+		t.Errorf("nothing should be logged while disabled, got %q and %q", console, output.String())
+	}
+	if len(*exits) != 0 {
+		t.Errorf("FATAL should not exit while disabled, got %v", *exits)
+	}
+	if allocs := testing.AllocsPerRun(100, func() { logger.Info("hidden") }); allocs != 0 {
+		t.Errorf("disabled logging should not allocate, got %v allocations", allocs)
+	}
+
+	config.SetEnabled(true)
+	console = captureConsole(t, func() { logger.Info("visible") })
+	if !strings.Contains(console, "visible") {
+		t.Errorf("expected the message once enabled again, got %q", console)
+	}
+}
+
+func TestSetErrorHandler(t *testing.T) {
+	config := GetConfiguration()
+	defer config.SetOutput(config.GetOutput())
+	defer config.SetErrorHandler(nil)
+	stderr := os.Stderr
+	defer func() { os.Stderr = stderr }()
+	os.Stderr, _ = os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	logger := New("TestErrorHandler")
+
+	var handled []error
+	config.SetErrorHandler(func(err error) {
+		handled = append(handled, err)
+		logger.Warn("sink failed: ", err)
+	})
+	config.SetOutput(failingWriter{})
+	console := captureConsole(t, func() { logger.Info("lost") })
+
+	if len(handled) != 1 || handled[0].Error() != "disk full" {
+		t.Errorf("expected the handler to get one failure, got %v", handled)
+	}
+	if !strings.Contains(console, "sink failed: disk full") {
+		t.Errorf("the handler should be able to log, got %q", console)
+	}
+}
+
+func TestSetErrorHandlerConcurrent(t *testing.T) {
+	config := GetConfiguration().Clone()
+	config.SetConsoleEnabled(false)
+	stderr := os.Stderr
+	defer func() { os.Stderr = stderr }()
+	devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer devNull.Close()
+	os.Stderr = devNull
+
+	var mu sync.Mutex
+	handled := 0
+	release := make(chan struct{})
+	config.SetErrorHandler(func(err error) {
+		<-release
+		mu.Lock()
+		handled++
+		mu.Unlock()
+	})
+	config.SetOutput(failingWriter{})
+	logger := config.New("TestErrorHandler")
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			logger.Info("lost")
+		}()
+	}
+	for start := time.Now(); config.handlerCalls.Load() < 4 && time.Since(start) < 5*time.Second; {
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	wg.Wait()
+	if handled != 4 {
+		t.Errorf("concurrent failures should all reach the handler, got %d", handled)
+	}
+}
+
+func TestConfigurationClone(t *testing.T) {
+	global := GetConfiguration()
+	defer global.SetLevel(global.GetLevel())
+	defer global.SetModuleAsField(global.GetModuleAsField())
+	defer global.SetSplitStreams(global.GetSplitStreams())
+	global.SetLevel(DebugLevel)
+	global.SetSplitStreams(true)
+
+	clone := global.Clone()
+	if !clone.GetSplitStreams() {
+		t.Error("the clone should keep the split streams setting")
+	}
+	var buf bytes.Buffer
+	clone.SetOutput(&buf)
+	clone.SetLevel(WarnLevel)
+	clone.SetModuleAsField(true)
+	if err := clone.SetLevelColor("WARN", "48;5;9"); err != nil {
+		t.Fatal(err)
+	}
+	if global.GetLevel() != DebugLevel || global.GetOutput() == &buf || global.GetModuleAsField() {
+		t.Fatal("changing the clone should not change the global configuration")
+	}
+	if color, _ := global.GetLevelColor("WARN"); color == "\033[48;5;9m" {
+		t.Fatal("the clone should have its own theme")
+	}
+
+	logger := clone.New("TestClone").With("k", "v")
+	captureConsole(t, func() {
+		logger.Info("filtered by the clone")
+		logger.Warn("kept")
+	})
+	if strings.Contains(buf.String(), "filtered by the clone") || !strings.Contains(buf.String(), "kept k=v module=TestClone") {
+		t.Errorf("the logger should follow the clone, got %q", buf.String())
+	}
+}
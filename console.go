@@ -0,0 +1,242 @@
+// Package orchid
+//Copyright (c) 2022 Epiphyte LLC. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+// Author: Fernandez-Alcon, Jose
+// e-mail: jose@epiphyte.io
+package orchid
+
+import (
+	"bufio"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+//How often a buffered console is flushed when nothing else flushes it
+const consoleFlushInterval = 100 * time.Millisecond
+
+//The width of separator lines when neither the terminal nor the COLUMNS environment variable gives a width
+const defaultSeparatorWidth = 80
+
+//Describes a console writer that buffers lines and flushes them periodically
+type bufferedConsole struct {
+	mu     sync.Mutex
+	target io.Writer     //The writer the buffer flushes to
+	buffer *bufio.Writer //Holds lines that were not flushed yet
+	stop   chan struct{} //Closed to stop the periodic flush
+}
+
+func newBufferedConsole(target io.Writer, size int) *bufferedConsole {
+	b := &bufferedConsole{
+		target: target,
+		buffer: bufio.NewWriterSize(target, size),
+		stop:   make(chan struct{}),
+	}
+	go b.flushPeriodically()
+	return b
+}
+
+func (b *bufferedConsole) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buffer.Write(p)
+}
+
+func (b *bufferedConsole) Flush() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buffer.Flush()
+}
+
+func (b *bufferedConsole) flushPeriodically() {
+	ticker := time.NewTicker(consoleFlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			b.Flush()
+		case <-b.stop:
+			return
+		}
+	}
+}
+
+//Flushes the remaining lines and stops the periodic flush
+func (b *bufferedConsole) close() error {
+	close(b.stop)
+	return b.Flush()
+}
+
+//Buffers console output in a buffer of n bytes, which speeds up printing many lines to a terminal.
+//The buffer is flushed periodically, after every WARN or more severe message and by Flush.
+//The console writer in use when this is called is the one buffered. Zero or less disables buffering.
+func (c *Configuration) SetConsoleBufferSize(n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.consoleBuffer != nil {
+		c.consoleBuffer.close()
+		c.consoleBuffer = nil
+	}
+	if n > 0 {
+		c.consoleBuffer = newBufferedConsole(c.consoleBase(), n)
+	}
+}
+
+//Writes the buffered console output and stops buffering, so later lines are printed right away
+func (c *Configuration) closeConsoleBuffer() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.consoleBuffer == nil {
+		return nil
+	}
+	err := c.consoleBuffer.close()
+	c.consoleBuffer = nil
+	return err
+}
+
+//Logs the pending sampling summaries, writes any buffered console output and waits for queued async
+//messages to be written
+func (c *Configuration) Flush() error {
+	c.flushSamples()
+	c.waitAsync()
+	return c.flushConsole()
+}
+
+func (c *Configuration) flushConsole() error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.consoleBuffer == nil {
+		return nil
+	}
+	return c.consoleBuffer.Flush()
+}
+
+//Logs the pending sampling summaries, writes any buffered console output and waits for queued async
+//messages to be written
+func Flush() error {
+	return configuration.Flush()
+}
+
+//Sends console output to os.Stdout, for tools whose logs are meant to be piped. By default the console
+//is the standard logger's writer, os.Stderr unless log.SetOutput changed it.
+func (c *Configuration) SetConsoleStdout(stdout bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.consoleStdout = stdout
+}
+
+func (c *Configuration) GetConsoleStdout() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.consoleStdout
+}
+
+//Prints DEBUG, INFO and OK messages on os.Stdout and WARN, ERROR and FATAL on os.Stderr, as many tools
+//expect. Colors are decided for each stream. While it is on, SetConsoleStdout and the console buffer
+//are ignored. Off by default, so the console is a single stream.
+func (c *Configuration) SetSplitStreams(split bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.splitStreams = split
+}
+
+func (c *Configuration) GetSplitStreams() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.splitStreams
+}
+
+//Returns the writer a message of the given severity is printed to, and the writer behind it used
+//to decide whether it is a terminal
+func (c *Configuration) consoleFor(severity string) (writer io.Writer, target io.Writer) {
+	if !c.GetSplitStreams() {
+		return c.consoleWriter(), c.consoleTarget()
+	}
+	if level, err := ParseLevel(severity); err == nil && level < WarnLevel {
+		return os.Stdout, os.Stdout
+	}
+	return os.Stderr, os.Stderr
+}
+
+//Returns the unbuffered console writer. The caller must hold c.mu.
+func (c *Configuration) consoleBase() io.Writer {
+	if c.consoleStdout {
+		return os.Stdout
+	}
+	return log.Writer()
+}
+
+//Returns the writer console lines are written to
+func (c *Configuration) consoleWriter() io.Writer {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.consoleBuffer != nil {
+		return c.consoleBuffer
+	}
+	return c.consoleBase()
+}
+
+//Returns the writer behind the console, used to decide whether it is a terminal
+func (c *Configuration) consoleTarget() io.Writer {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.consoleBuffer != nil {
+		return c.consoleBuffer.target
+	}
+	return c.consoleBase()
+}
+
+//Sets the character separator lines are drawn with, '-' by default
+func (c *Configuration) SetSeparatorChar(char rune) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.separatorChar = char
+}
+
+func (c *Configuration) GetSeparatorChar() rune {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.separatorChar == 0 {
+		return '-'
+	}
+	return c.separatorChar
+}
+
+//Returns the width of the terminal target writes to, falling back to the COLUMNS environment variable
+//when target is not a terminal and to defaultSeparatorWidth when that is unset
+func separatorWidth(target io.Writer) int {
+	if columns := terminalWidth(target); columns > 0 {
+		return columns
+	}
+	if columns, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && columns > 0 {
+		return columns
+	}
+	return defaultSeparatorWidth
+}
+
+//Prints a horizontal rule across the console to set apart sections or test runs. It is not written to files.
+func (c *Configuration) Separator() {
+	if !c.GetConsoleEnabled() {
+		return
+	}
+	writer, target := c.consoleFor("INFO")
+	line := strings.Repeat(string(c.GetSeparatorChar()), separatorWidth(target)) + "\n"
+	redraw := c.getProgressRedraw()
+	if redraw != nil {
+		line = CLEAR_LINE + line
+	}
+	io.WriteString(writer, line)
+	if redraw != nil {
+		redraw()
+	}
+}
+
+//Prints a horizontal rule across the console to set apart sections or test runs. It is not written to files.
+func Separator() {
+	configuration.Separator()
+}
@@ -0,0 +1,191 @@
+// Package orchid
+//Copyright (c) 2022 Epiphyte LLC. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+// Author: Fernandez-Alcon, Jose
+// e-mail: jose@epiphyte.io
+package orchid
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBufferedConsole(t *testing.T) {
+	config := GetConfiguration()
+	defer config.SetConsoleBufferSize(0)
+	Init("TestFramework")
+
+	var buf bytes.Buffer
+	writer := log.Writer()
+	log.SetOutput(&buf)
+	defer log.SetOutput(writer)
+
+	config.SetConsoleBufferSize(4096)
+	Info("buffered")
+	if buf.Len() != 0 {
+		t.Fatalf("INFO should stay in the buffer, got %q", buf.String())
+	}
+	if err := Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "buffered") {
+		t.Fatalf("Flush should write the buffered line, got %q", buf.String())
+	}
+
+	buf.Reset()
+	Debug("first")
+	Warn("second")
+	if !strings.Contains(buf.String(), "first") || !strings.Contains(buf.String(), "second") {
+		t.Errorf("WARN should flush the buffer, got %q", buf.String())
+	}
+}
+
+func TestBufferedConsoleClose(t *testing.T) {
+	config := GetConfiguration().Clone()
+	var buf bytes.Buffer
+	writer := log.Writer()
+	log.SetOutput(&buf)
+	defer log.SetOutput(writer)
+
+	config.SetConsoleBufferSize(4096)
+	config.New("TestFramework").Info("buffered until Close")
+	if err := config.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "buffered until Close") {
+		t.Fatalf("Close should write the buffered line, got %q", buf.String())
+	}
+	if config.consoleBuffer != nil {
+		t.Error("Close should stop the console buffer")
+	}
+}
+
+func benchmarkConsole(b *testing.B, bufferSize int) {
+	file, err := os.Create(filepath.Join(b.TempDir(), "console.log"))
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer file.Close()
+	writer := log.Writer()
+	log.SetOutput(file)
+	defer log.SetOutput(writer)
+	config := GetConfiguration()
+	config.SetConsoleBufferSize(bufferSize)
+	defer config.SetConsoleBufferSize(0)
+	Init("Benchmark")
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		Info("benchmarking the console ", i)
+	}
+	Flush()
+}
+
+func BenchmarkConsoleUnbuffered(b *testing.B) {
+	benchmarkConsole(b, 0)
+}
+
+func BenchmarkConsoleBuffered(b *testing.B) {
+	benchmarkConsole(b, 64*1024)
+}
+
+func TestConsoleStdout(t *testing.T) {
+	config := GetConfiguration()
+	defer config.SetConsoleStdout(false)
+	Init("TestFramework")
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	stderr := captureConsole(t, func() {
+		Info("to stderr")
+		config.SetConsoleStdout(true)
+		Info("to stdout")
+	})
+	w.Close()
+	var piped bytes.Buffer
+	piped.ReadFrom(r)
+
+	if !strings.Contains(stderr, "to stderr") || strings.Contains(stderr, "to stdout") {
+		t.Errorf("unexpected standard logger output %q", stderr)
+	}
+	if !strings.Contains(piped.String(), "to stdout") || strings.Contains(piped.String(), "to stderr") {
+		t.Errorf("unexpected stdout output %q", piped.String())
+	}
+}
+
+func TestSeparator(t *testing.T) {
+	config := GetConfiguration()
+	defer config.SetSeparatorChar(0)
+	t.Setenv("COLUMNS", "40")
+	output := captureOutput(t)
+
+	config.SetSeparatorChar('=')
+	console := captureConsole(t, func() { New("TestSeparator").Separator() })
+	if console != strings.Repeat("=", 40)+"\n" {
+		t.Errorf("expected a 40 column rule, got %q", console)
+	}
+	if output.Len() != 0 {
+		t.Errorf("the separator should not reach the output, got %q", output.String())
+	}
+
+	t.Setenv("COLUMNS", "")
+	console = captureConsole(t, Separator)
+	if console != strings.Repeat("=", defaultSeparatorWidth)+"\n" {
+		t.Errorf("expected a %d column rule without COLUMNS, got %q", defaultSeparatorWidth, console)
+	}
+}
+
+func TestSplitStreams(t *testing.T) {
+	config := GetConfiguration()
+	defer config.SetSplitStreams(false)
+	dir := t.TempDir()
+	stdout, err := os.Create(filepath.Join(dir, "stdout"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	stderr, err := os.Create(filepath.Join(dir, "stderr"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	originalStdout, originalStderr := os.Stdout, os.Stderr
+	defer func() { os.Stdout, os.Stderr = originalStdout, originalStderr }()
+	os.Stdout, os.Stderr = stdout, stderr
+
+	logger := New("TestSplit")
+	config.SetSplitStreams(true)
+	console := captureConsole(t, func() {
+		logger.Debug("debug line")
+		logger.Info("info line")
+		logger.Warn("warn line")
+		logger.Error("error line")
+	})
+	config.SetSplitStreams(false)
+	single := captureConsole(t, func() { logger.Info("single stream") })
+	os.Stdout, os.Stderr = originalStdout, originalStderr
+
+	out, _ := os.ReadFile(stdout.Name())
+	errOut, _ := os.ReadFile(stderr.Name())
+	if console != "" {
+		t.Errorf("split streams should bypass the standard logger's writer, got %q", console)
+	}
+	if !strings.Contains(string(out), "debug line") || !strings.Contains(string(out), "info line") || strings.Contains(string(out), "warn line") {
+		t.Errorf("stdout should have the levels below WARN, got %q", out)
+	}
+	if !strings.Contains(string(errOut), "warn line") || !strings.Contains(string(errOut), "error line") || strings.Contains(string(errOut), "info line") {
+		t.Errorf("stderr should have WARN and above, got %q", errOut)
+	}
+	if !strings.Contains(single, "single stream") {
+		t.Errorf("turning it off should restore the single stream, got %q", single)
+	}
+}
@@ -0,0 +1,128 @@
+// Package orchid
+//Copyright (c) 2022 Epiphyte LLC. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+// Author: Fernandez-Alcon, Jose
+// e-mail: jose@epiphyte.io
+package orchid
+
+import (
+	"context"
+)
+
+//Registers fn to pull key-value pairs such as request or trace ids from the context given to
+//InfoContext and its peers. The pairs of every extractor, in registration order, are added to
+//the message fields as if passed to With. A trailing key without a value is ignored.
+func (c *Configuration) AddContextExtractor(fn func(ctx context.Context) []interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.contextExtractors = append(c.contextExtractors, fn)
+}
+
+//Removes every extractor added with AddContextExtractor
+func (c *Configuration) ClearContextExtractors() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.contextExtractors = nil
+}
+
+//Returns fields followed by the fields extracted from ctx, in a new slice when there are any
+func (c *Configuration) contextFields(ctx context.Context, fields []Field) []Field {
+	c.mu.RLock()
+	extractors := c.contextExtractors
+	c.mu.RUnlock()
+	if ctx == nil || len(extractors) == 0 {
+		return fields
+	}
+	var extracted []interface{}
+	for _, fn := range extractors {
+		extracted = append(extracted, fn(ctx)...)
+	}
+	if len(extracted) < 2 {
+		return fields
+	}
+	return appendFields(append(make([]Field, 0, len(fields)+len(extracted)/2), fields...), extracted)
+}
+
+//Returns a child logger whose messages all carry the fields the context extractors find in ctx, as if
+//every call were InfoContext and its peers, e.g. for one logger serving a whole request:
+//
+//	reqLogger := logger.WithContext(r.Context())
+//	reqLogger.Info("request received")
+//
+//The extractors run when each message is logged. lg itself is not bound to ctx.
+func (lg *Logger) WithContext(ctx context.Context) *Logger {
+	child := lg.With()
+	child.ctx = ctx
+	return child
+}
+
+func (lg *Logger) logContext(ctx context.Context, severity string, a ...interface{}) {
+	if !lg.conf().GetEnabled() {
+		return
+	}
+	var l logMessage
+	l.createLogMessage(lg, severity, a...)
+	l.Fields = lg.conf().contextFields(ctx, l.Fields)
+	l.printLogMessage()
+}
+
+//Logs like Info, adding the fields the context extractors find in ctx
+func (lg *Logger) InfoContext(ctx context.Context, a ...interface{}) {
+	lg.logContext(ctx, "INFO", a...)
+}
+
+//Logs like OK, adding the fields the context extractors find in ctx
+func (lg *Logger) OKContext(ctx context.Context, a ...interface{}) {
+	lg.logContext(ctx, "OK", a...)
+}
+
+//Logs like Error, adding the fields the context extractors find in ctx
+func (lg *Logger) ErrorContext(ctx context.Context, a ...interface{}) {
+	lg.logContext(ctx, "ERROR", a...)
+}
+
+//Logs like Fatal, adding the fields the context extractors find in ctx
+func (lg *Logger) FatalContext(ctx context.Context, a ...interface{}) {
+	lg.logContext(ctx, "FATAL", a...)
+}
+
+//Logs like Warn, adding the fields the context extractors find in ctx
+func (lg *Logger) WarnContext(ctx context.Context, a ...interface{}) {
+	lg.logContext(ctx, "WARN", a...)
+}
+
+//Logs like Debug, adding the fields the context extractors find in ctx
+func (lg *Logger) DebugContext(ctx context.Context, a ...interface{}) {
+	lg.logContext(ctx, "DEBUG", a...)
+}
+
+//Logs like Info, adding the fields the context extractors find in ctx
+func InfoContext(ctx context.Context, a ...interface{}) {
+	defaultLogger.logContext(ctx, "INFO", a...)
+}
+
+//Logs like OK, adding the fields the context extractors find in ctx
+func OKContext(ctx context.Context, a ...interface{}) {
+	defaultLogger.logContext(ctx, "OK", a...)
+}
+
+//Logs like Error, adding the fields the context extractors find in ctx
+func ErrorContext(ctx context.Context, a ...interface{}) {
+	defaultLogger.logContext(ctx, "ERROR", a...)
+}
+
+//Logs like Fatal, adding the fields the context extractors find in ctx
+func FatalContext(ctx context.Context, a ...interface{}) {
+	defaultLogger.logContext(ctx, "FATAL", a...)
+}
+
+//Logs like Warn, adding the fields the context extractors find in ctx
+func WarnContext(ctx context.Context, a ...interface{}) {
+	defaultLogger.logContext(ctx, "WARN", a...)
+}
+
+//Logs like Debug, adding the fields the context extractors find in ctx
+func DebugContext(ctx context.Context, a ...interface{}) {
+	defaultLogger.logContext(ctx, "DEBUG", a...)
+}
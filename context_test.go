@@ -0,0 +1,80 @@
+// Package orchid
+//Copyright (c) 2022 Epiphyte LLC. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+// Author: Fernandez-Alcon, Jose
+// e-mail: jose@epiphyte.io
+package orchid
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+type requestIDKey struct{}
+
+func TestContextExtractor(t *testing.T) {
+	config := GetConfiguration()
+	defer config.ClearContextExtractors()
+	defer config.SetIncludeCaller(config.GetIncludeCaller())
+
+	config.AddContextExtractor(func(ctx context.Context) []interface{} {
+		if id, ok := ctx.Value(requestIDKey{}).(string); ok {
+			return []interface{}{"request_id", id}
+		}
+		return nil
+	})
+	config.AddContextExtractor(func(ctx context.Context) []interface{} {
+		return []interface{}{"dangling"}
+	})
+	config.SetIncludeCaller(true)
+	ctx := context.WithValue(context.Background(), requestIDKey{}, "r-42")
+	logger := New("TestContext").With("user", "alice")
+
+	console := captureConsole(t, func() {
+		logger.WarnContext(ctx, "slow request")
+		InfoContext(context.Background(), "no request")
+	})
+	lines := strings.Split(strings.TrimSuffix(console, "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected two lines, got %q", console)
+	}
+	if !strings.Contains(lines[0], "slow request (context_test.go:") || !strings.HasSuffix(lines[0], "user=alice request_id=r-42") {
+		t.Errorf("expected the caller and the extracted field, got %q", lines[0])
+	}
+	if strings.Contains(lines[1], "request_id") || strings.Contains(lines[1], "dangling") {
+		t.Errorf("unexpected fields %q", lines[1])
+	}
+	if len(logger.fields) != 1 {
+		t.Errorf("extracted fields should not be added to the logger, got %v", logger.fields)
+	}
+}
+
+func TestWithContext(t *testing.T) {
+	config := GetConfiguration()
+	defer config.ClearContextExtractors()
+	config.AddContextExtractor(func(ctx context.Context) []interface{} {
+		if id, ok := ctx.Value(requestIDKey{}).(string); ok {
+			return []interface{}{"request_id", id}
+		}
+		return nil
+	})
+	logger := New("TestContext")
+	bound := logger.WithContext(context.WithValue(context.Background(), requestIDKey{}, "r-7")).With("user", "bob")
+
+	console := captureConsole(t, func() {
+		bound.Info("plain call")
+		logger.Info("parent call")
+	})
+	lines := strings.Split(strings.TrimSuffix(console, "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected two lines, got %q", console)
+	}
+	if !strings.HasSuffix(lines[0], "plain call user=bob request_id=r-7") {
+		t.Errorf("the bound context should apply to plain calls, got %q", lines[0])
+	}
+	if strings.Contains(lines[1], "request_id") {
+		t.Errorf("the context should not leak to the parent, got %q", lines[1])
+	}
+}
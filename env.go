@@ -0,0 +1,83 @@
+// Package orchid
+//Copyright (c) 2022 Epiphyte LLC. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+// Author: Fernandez-Alcon, Jose
+// e-mail: jose@epiphyte.io
+package orchid
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+//Applies the settings found in the environment, so deployments can tune logging without code changes:
+//
+//	ORCHID_LEVEL   minimum level, as accepted by ParseLevel
+//	ORCHID_FORMAT  console format, text or json
+//	ORCHID_FILE    path of the log file, see SetLogFile
+//	ORCHID_COLOR   auto, always or never
+//	ORCHID_UTC     whether timestamps are in UTC, as accepted by strconv.ParseBool
+//
+//Unset or empty variables leave the current settings untouched. Malformed values are skipped and
+//reported together in the returned error, while the valid ones are still applied.
+func (c *Configuration) ConfigureFromEnv() error {
+	var errs []error
+	env := func(name string, apply func(string) error) {
+		value := strings.TrimSpace(os.Getenv(name))
+		if value == "" {
+			return
+		}
+		if err := apply(value); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		}
+	}
+	env("ORCHID_LEVEL", func(value string) error {
+		level, err := ParseLevel(value)
+		if err == nil {
+			c.SetLevel(level)
+		}
+		return err
+	})
+	env("ORCHID_FORMAT", func(value string) error {
+		switch strings.ToLower(value) {
+		case "text":
+			c.SetConsoleFormat(FormatText)
+		case "json":
+			c.SetConsoleFormat(FormatJSON)
+		default:
+			return fmt.Errorf("%w %q", ErrInvalidFormat, value)
+		}
+		return nil
+	})
+	env("ORCHID_COLOR", func(value string) error {
+		switch strings.ToLower(value) {
+		case "auto":
+			c.SetColorMode(ColorAuto)
+		case "always":
+			c.SetColorMode(ColorAlways)
+		case "never":
+			c.SetColorMode(ColorNever)
+		default:
+			return fmt.Errorf("%w %q", ErrInvalidColorMode, value)
+		}
+		return nil
+	})
+	env("ORCHID_UTC", func(value string) error {
+		utc, err := strconv.ParseBool(value)
+		if err == nil {
+			c.SetUseUTC(utc)
+		}
+		return err
+	})
+	env("ORCHID_FILE", c.SetLogFile)
+	return errors.Join(errs...)
+}
+
+//Applies the ORCHID_* environment variables to the global configuration
+func ConfigureFromEnv() error {
+	return configuration.ConfigureFromEnv()
+}
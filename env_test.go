@@ -0,0 +1,51 @@
+// Package orchid
+//Copyright (c) 2022 Epiphyte LLC. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+// Author: Fernandez-Alcon, Jose
+// e-mail: jose@epiphyte.io
+package orchid
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestConfigureFromEnv(t *testing.T) {
+	config := GetConfiguration().Clone()
+	path := filepath.Join(t.TempDir(), "env.log")
+	t.Setenv("ORCHID_LEVEL", "warning")
+	t.Setenv("ORCHID_FORMAT", "JSON")
+	t.Setenv("ORCHID_FILE", path)
+	t.Setenv("ORCHID_COLOR", "never")
+	t.Setenv("ORCHID_UTC", "true")
+
+	if err := config.ConfigureFromEnv(); err != nil {
+		t.Fatal(err)
+	}
+	defer config.Close()
+	if config.GetLevel() != WarnLevel || config.GetConsoleFormat() != FormatJSON || config.GetLogFile() != path ||
+		config.GetColorMode() != ColorNever || !config.GetUseUTC() {
+		t.Errorf("expected every variable to be applied, got level %v format %v file %q color %v utc %v",
+			config.GetLevel(), config.GetConsoleFormat(), config.GetLogFile(), config.GetColorMode(), config.GetUseUTC())
+	}
+}
+
+func TestConfigureFromEnvErrors(t *testing.T) {
+	config := GetConfiguration().Clone()
+	config.SetLevel(InfoLevel)
+	t.Setenv("ORCHID_LEVEL", "loud")
+	t.Setenv("ORCHID_FORMAT", "")
+	t.Setenv("ORCHID_FILE", "")
+	t.Setenv("ORCHID_COLOR", "rainbow")
+	t.Setenv("ORCHID_UTC", "1")
+
+	err := config.ConfigureFromEnv()
+	if err == nil || !strings.Contains(err.Error(), "ORCHID_LEVEL") || !strings.Contains(err.Error(), "ORCHID_COLOR") {
+		t.Fatalf("expected the malformed variables to be reported together, got %v", err)
+	}
+	if config.GetLevel() != InfoLevel || config.GetConsoleFormat() != FormatText || !config.GetUseUTC() {
+		t.Error("malformed and empty variables should be skipped while valid ones are applied")
+	}
+}
@@ -0,0 +1,27 @@
+// Package orchid
+//Copyright (c) 2022 Epiphyte LLC. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+// Author: Fernandez-Alcon, Jose
+// e-mail: jose@epiphyte.io
+package orchid
+
+import "errors"
+
+//Errors returned, wrapped with the offending value, by the functions validating their input. Match them
+//with errors.Is rather than by message:
+//
+//	if errors.Is(err, orchid.ErrUnknownLevel) { ... }
+//
+//File errors are the ones of the os package, so errors.Is(err, fs.ErrNotExist) and similar checks apply.
+var (
+	ErrEmptyModule        = errors.New("invalid module name")        //Init or SetModule got an empty or blank name
+	ErrModuleTooLong      = errors.New("module name too long")       //Init or SetModule got a name over MaxModuleLength bytes
+	ErrAlreadyInitialized = errors.New("logger already initialized") //Init was called again under SetStrictInit
+	ErrUnknownLevel       = errors.New("unknown log level")          //A level name did not match any Level
+	ErrInvalidFormat      = errors.New("unknown format")             //A console format name is not text or json
+	ErrInvalidColorMode   = errors.New("unknown color mode")         //A color mode name is not auto, always or never
+	ErrInvalidColor       = errors.New("invalid ANSI color")         //A theme color is not an SGR escape sequence
+	ErrUnknownJSONField   = errors.New("unknown JSON field")         //SetJSONFieldNames got a key other than time, severity, module or text
+	ErrPathTooLong        = errors.New("log file path too long")     //A log file path is over MaxPathLength bytes
+)
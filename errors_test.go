@@ -0,0 +1,54 @@
+// Package orchid
+//Copyright (c) 2022 Epiphyte LLC. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+// Author: Fernandez-Alcon, Jose
+// e-mail: jose@epiphyte.io
+package orchid
+
+import (
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSentinelErrors(t *testing.T) {
+	config := GetConfiguration()
+	defer config.SetStrictInit(config.GetStrictInit())
+	config.SetStrictInit(true)
+	_, levelErr := ParseLevel("loud")
+	_, themeErr := LoadThemeFromJSON([]byte(`{"colors":{"INFO":"blue"}}`))
+	_, themeLevelErr := LoadThemeFromJSON([]byte(`{"colors":{"LOUD":"31"}}`))
+	envConfig := config.Clone()
+	t.Setenv("ORCHID_FORMAT", "xml")
+	t.Setenv("ORCHID_COLOR", "rainbow")
+	envErr := envConfig.ConfigureFromEnv()
+
+	tests := []struct {
+		name     string
+		err      error
+		sentinel error
+		message  string
+	}{
+		{"empty module", New("Module").SetModule(" "), ErrEmptyModule, `invalid module name " "`},
+		{"long module", New("Module").SetModule(strings.Repeat("m", MaxModuleLength+1)), ErrModuleTooLong, "module name too long: 257 bytes, the limit is 256"},
+		{"strict init", New("Module").Init("Again"), ErrAlreadyInitialized, `logger already initialized as "Module"`},
+		{"level", levelErr, ErrUnknownLevel, `unknown log level "loud"`},
+		{"theme color", themeErr, ErrInvalidColor, `theme: invalid ANSI color "blue" for INFO`},
+		{"theme level", themeLevelErr, ErrUnknownLevel, `theme: unknown log level "LOUD"`},
+		{"env format", envErr, ErrInvalidFormat, ""},
+		{"env color", envErr, ErrInvalidColorMode, ""},
+		{"long path", config.Clone().SetLogFile(strings.Repeat("p", MaxPathLength+1)), ErrPathTooLong, "log file path too long: 4097 bytes, the limit is 4096"},
+		{"file", config.Clone().SetLogFile(filepath.Join(t.TempDir(), "missing", "app.log")), fs.ErrNotExist, ""},
+	}
+	for _, test := range tests {
+		if !errors.Is(test.err, test.sentinel) {
+			t.Errorf("%s: expected errors.Is(%v, %v)", test.name, test.err, test.sentinel)
+		}
+		if test.message != "" && test.err.Error() != test.message {
+			t.Errorf("%s: expected the message %q, got %q", test.name, test.message, test.err.Error())
+		}
+	}
+}
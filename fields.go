@@ -0,0 +1,65 @@
+// Package orchid
+//Copyright (c) 2022 Epiphyte LLC. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+// Author: Fernandez-Alcon, Jose
+// e-mail: jose@epiphyte.io
+package orchid
+
+import (
+	"fmt"
+	"time"
+)
+
+func String(key string, value string) Field {
+	return Field{Key: key, Value: value}
+}
+
+func Int(key string, value int) Field {
+	return Field{Key: key, Value: value}
+}
+
+func Int64(key string, value int64) Field {
+	return Field{Key: key, Value: value}
+}
+
+func Float64(key string, value float64) Field {
+	return Field{Key: key, Value: value}
+}
+
+func Bool(key string, value bool) Field {
+	return Field{Key: key, Value: value}
+}
+
+func Duration(key string, value time.Duration) Field {
+	return Field{Key: key, Value: value}
+}
+
+//Returns an error field under the key "error"
+func Err(err error) Field {
+	return Field{Key: "error", Value: err}
+}
+
+func Any(key string, value interface{}) Field {
+	return Field{Key: key, Value: value}
+}
+
+//Separates the Field arguments of a log call from the ones that make up its text
+func splitFields(a []interface{}) (string, []Field) {
+	var fields []Field
+	for _, arg := range a {
+		if f, ok := arg.(Field); ok {
+			fields = append(fields, f)
+		}
+	}
+	if fields == nil {
+		return fmt.Sprint(a...), nil
+	}
+	rest := make([]interface{}, 0, len(a)-len(fields))
+	for _, arg := range a {
+		if _, ok := arg.(Field); !ok {
+			rest = append(rest, arg)
+		}
+	}
+	return fmt.Sprint(rest...), fields
+}
@@ -0,0 +1,35 @@
+// Package orchid
+//Copyright (c) 2022 Epiphyte LLC. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+// Author: Fernandez-Alcon, Jose
+// e-mail: jose@epiphyte.io
+package orchid
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFieldArguments(t *testing.T) {
+	logger := New("TestFields").With("user", "alice")
+	ch, unsubscribe := GetConfiguration().Subscribe()
+	defer unsubscribe()
+
+	console := captureConsole(t, func() {
+		logger.Info("request served", Int("status", 200), Duration("took", 1500*time.Millisecond), Err(errors.New("none")), String("path", "/a b"))
+	})
+	record := <-ch
+
+	if !strings.HasSuffix(console, `request served user=alice status=200 took=1.5s error=none path="/a b"`+"\n") {
+		t.Errorf("fields should follow the text, got %q", console)
+	}
+	if record.Fields["status"] != 200 || record.Fields["took"] != 1500*time.Millisecond {
+		t.Errorf("fields should keep their types, got %#v", record.Fields)
+	}
+	if len(logger.fields) != 1 {
+		t.Errorf("the logger fields should not change, got %v", logger.fields)
+	}
+}
@@ -0,0 +1,487 @@
+// Package orchid
+//Copyright (c) 2022 Epiphyte LLC. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+// Author: Fernandez-Alcon, Jose
+// e-mail: jose@epiphyte.io
+package orchid
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+//The layout of the timestamp of file lines unless SetTimeFormat is used
+const DefaultTimeFormat = "2006-01-02 15:04:05"
+
+//Describes the last timestamp written to a file, reused while later lines fall in the same second
+type timestampCache struct {
+	layout   string         //The layout text was formatted with
+	second   int64          //The Unix second text was formatted for
+	location *time.Location //The location text was formatted in
+	text     string         //The formatted timestamp, empty when nothing is cached
+}
+
+//Returns t formatted with layout, reusing the previous result when t is in the same second.
+//Layouts with fractional seconds change within a second and are always formatted.
+func (c *timestampCache) format(t time.Time, layout string) string {
+	if strings.Contains(layout, ".0") || strings.Contains(layout, ".9") ||
+		strings.Contains(layout, ",0") || strings.Contains(layout, ",9") {
+		return t.Format(layout)
+	}
+	second := t.Unix()
+	if c.text == "" || c.second != second || c.layout != layout || c.location != t.Location() {
+		c.layout, c.second, c.location, c.text = layout, second, t.Location(), t.Format(layout)
+	}
+	return c.text
+}
+
+//Describes a log file opened and owned by orchid
+type logFile struct {
+	path   string    //The path given to SetLogFile, dated copies are derived from it
+	file   *os.File  //The file messages are currently written to
+	period time.Time //Start of the rotation interval the open file belongs to
+	level  Level     //Messages below this level are not written to the file
+	format Format    //Whether lines are written as text or JSON
+	mkdir  bool      //Whether missing parent directories are created, see SetCreateDirs
+}
+
+//Opens path for appending, first creating its parent directories when mkdir is set
+func openLogFile(path string, mkdir bool) (*os.File, error) {
+	if mkdir {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return nil, err
+		}
+	}
+	return os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+}
+
+//Returns the start of the rotation interval t belongs to, aligned to the wall clock of t's location, so
+//daily intervals start at midnight of the calendar date even on days with a daylight saving change
+func rotationPeriod(t time.Time, interval time.Duration) time.Time {
+	wall := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+	start := wall.Truncate(interval)
+	return time.Date(start.Year(), start.Month(), start.Day(), start.Hour(), start.Minute(), start.Second(), 0, t.Location())
+}
+
+//Returns the name of the file for the interval starting at period, e.g. app-2024-01-02.log for app.log
+func rotatedPath(path string, period time.Time, interval time.Duration) string {
+	layout := "2006-01-02"
+	if interval%(24*time.Hour) != 0 {
+		layout = "2006-01-02T15-04-05"
+	}
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + "-" + period.Format(layout) + ext
+}
+
+//Makes sure the open file is the one for t, starting a new file when t is in a new rotation interval
+func (f *logFile) rotate(t time.Time, interval time.Duration) error {
+	if interval <= 0 {
+		return nil
+	}
+	period := rotationPeriod(t, interval)
+	if f.file != nil && period.Equal(f.period) {
+		return nil
+	}
+	path := rotatedPath(f.path, period, interval)
+	if f.file != nil && path == f.file.Name() {
+		//The period moved without changing the name, e.g. after the location changed
+		f.period = period
+		return nil
+	}
+	file, err := openLogFile(path, f.mkdir)
+	if err != nil {
+		return err
+	}
+	if f.file != nil {
+		f.file.Close()
+	}
+	f.file, f.period = file, period
+	return nil
+}
+
+//Makes sure f is the file for t like logFile.rotate, compressing the file it replaces in the
+//background when SetCompressRotated is on
+func (c *Configuration) rotate(f *logFile, t time.Time, interval time.Duration) error {
+	previous := f.file
+	if err := f.rotate(t, interval); err != nil {
+		return err
+	}
+	if previous != nil && f.file != previous && previous.Name() != f.file.Name() && c.GetCompressRotated() {
+		c.compressing.Add(1)
+		go func() {
+			defer c.compressing.Done()
+			if err := compressFile(previous.Name()); err != nil {
+				c.reportError("ORCHID FILE ERROR:", err)
+			}
+		}()
+	}
+	return nil
+}
+
+//Compresses path into path.gz and removes path. An existing path.gz gets the data as a new gzip
+//member, which gunzip reads as a continuation of the same file.
+func compressFile(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	dst, err := os.OpenFile(path+".gz", os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	zw := gzip.NewWriter(dst)
+	if _, err := io.Copy(zw, src); err != nil {
+		zw.Close()
+		dst.Close()
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		dst.Close()
+		return err
+	}
+	if err := dst.Close(); err != nil {
+		return err
+	}
+	src.Close()
+	return os.Remove(path)
+}
+
+//Compresses every log file replaced by rotation into a .gz file next to it and removes the original.
+//Compression runs in the background, Close waits for it to finish.
+func (c *Configuration) SetCompressRotated(compress bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.compressRotated = compress
+}
+
+func (c *Configuration) GetCompressRotated() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.compressRotated
+}
+
+//Describes the file a Logger writes to instead of the global destinations, shared with its children
+type loggerFile struct {
+	mu   sync.Mutex
+	file *logFile //The file set with Logger.SetFile, nil to use the global destinations
+}
+
+//Writes the message to the logger's file, reporting false when the logger has none
+func (f *loggerFile) write(l *logMessage, c *Configuration) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.file == nil {
+		return false, nil
+	}
+	if err := c.rotate(f.file, l.Time, c.GetRotationInterval()); err != nil {
+		return true, err
+	}
+	if f.file.format == FormatJSON {
+		_, err := fmt.Fprintln(f.file.file, string(l.jsonFileText()))
+		return true, err
+	}
+	_, err := fmt.Fprintln(f.file.file, l.fileLine(l.Time.Format(c.GetTimeFormat())))
+	return true, err
+}
+
+//Opens path in append mode and writes the messages of this logger and its children only there, in
+//format, instead of the output, log files and syslog of the configuration. An audit logger can write
+//JSON to audit.log while the others write text. The console is unchanged.
+//The file is rotated like the SetLogFile file. It belongs to the logger: the global Close does not
+//close it, so loggers with a file must be closed individually with Logger.Close or an empty path.
+func (lg *Logger) SetFile(path string, format Format) error {
+	var next *logFile
+	if path != "" {
+		var err error
+		if next, err = lg.conf().newLogFile(path, format, DebugLevel); err != nil {
+			return err
+		}
+	}
+	lg.mu.Lock()
+	if lg.file == nil {
+		lg.file = &loggerFile{}
+	}
+	holder := lg.file
+	lg.mu.Unlock()
+	holder.mu.Lock()
+	previous := holder.file
+	holder.file = next
+	holder.mu.Unlock()
+	if previous != nil {
+		return previous.file.Close()
+	}
+	return nil
+}
+
+//Closes the file set with SetFile, which the global Close leaves open, and returns the logger and
+//its children to the global destinations. The global configuration is not affected.
+func (lg *Logger) Close() error {
+	return lg.SetFile("", FormatText)
+}
+
+//Returns the name of the file set with SetFile, empty when the logger uses the global destinations
+func (lg *Logger) GetFile() string {
+	lg.mu.RLock()
+	holder := lg.file
+	lg.mu.RUnlock()
+	if holder == nil {
+		return ""
+	}
+	holder.mu.Lock()
+	defer holder.mu.Unlock()
+	if holder.file == nil {
+		return ""
+	}
+	return holder.file.file.Name()
+}
+
+//The longest log file path, in bytes, that SetLogFile, AddFileSink and Logger.SetFile accept
+const MaxPathLength = 4096
+
+//Opens the log file for path, already rotated to the current interval when rotation is enabled
+func (c *Configuration) newLogFile(path string, format Format, level Level) (*logFile, error) {
+	if len(path) > MaxPathLength {
+		return nil, fmt.Errorf("%w: %d bytes, the limit is %d", ErrPathTooLong, len(path), MaxPathLength)
+	}
+	f := &logFile{path: path, level: level, format: format, mkdir: c.GetCreateDirs()}
+	if interval := c.GetRotationInterval(); interval > 0 {
+		if err := f.rotate(c.now(), interval); err != nil {
+			return nil, err
+		}
+		return f, nil
+	}
+	file, err := openLogFile(path, f.mkdir)
+	if err != nil {
+		return nil, err
+	}
+	f.file = file
+	return f, nil
+}
+
+//Opens path in append mode and writes every message to it as a plain text line.
+//A writer set with SetOutput takes precedence over the file. An empty path closes the file.
+func (c *Configuration) SetLogFile(path string) error {
+	var next *logFile
+	if path != "" {
+		var err error
+		if next, err = c.newLogFile(path, FormatText, DebugLevel); err != nil {
+			return err
+		}
+	}
+	c.outputMu.Lock()
+	previous := c.logFile
+	c.logFile = next
+	c.outputMu.Unlock()
+	if previous != nil {
+		return previous.file.Close()
+	}
+	return nil
+}
+
+//Opens path in append mode and also writes every message at or above minLevel to it in format, for
+//example a separate error.log with only WARN and above, or a JSON copy for a collector. Each file
+//keeps its own handle and is rotated like the SetLogFile file. Unlike that file, it is written even
+//when SetOutput is used.
+func (c *Configuration) AddFileSink(path string, format Format, minLevel Level) error {
+	sink, err := c.newLogFile(path, format, minLevel)
+	if err != nil {
+		return err
+	}
+	c.outputMu.Lock()
+	defer c.outputMu.Unlock()
+	c.fileSinks = append(c.fileSinks, sink)
+	return nil
+}
+
+//Closes the files opened by AddFileSink, returning the first error
+func (c *Configuration) closeFileSinks() error {
+	c.outputMu.Lock()
+	sinks := c.fileSinks
+	c.fileSinks = nil
+	c.outputMu.Unlock()
+	var errs []error
+	for _, sink := range sinks {
+		if err := sink.file.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("file sink %s: %w", sink.path, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (c *Configuration) GetLogFile() string {
+	c.outputMu.Lock()
+	defer c.outputMu.Unlock()
+	if c.logFile == nil {
+		return ""
+	}
+	return c.logFile.file.Name()
+}
+
+//Also sends every message to the syslog daemon at addr, as the module followed by the text.
+//The severity selects the priority: DEBUG is LOG_DEBUG, INFO LOG_INFO, OK LOG_NOTICE, WARN LOG_WARNING,
+//ERROR LOG_ERR and FATAL LOG_CRIT. An empty network connects to the local daemon, and an empty tag
+//uses the program name. It returns an error where syslog is unavailable, such as on Windows.
+func (c *Configuration) SetSyslog(network, addr, tag string) error {
+	sink, err := dialSyslog(network, addr, tag)
+	if err != nil {
+		return err
+	}
+	c.outputMu.Lock()
+	previous := c.syslog
+	c.syslog = sink
+	c.outputMu.Unlock()
+	if previous != nil {
+		return previous.close()
+	}
+	return nil
+}
+
+//Closes the syslog connection opened by SetSyslog, if any
+func (c *Configuration) closeSyslog() error {
+	c.outputMu.Lock()
+	sink := c.syslog
+	c.syslog = nil
+	c.outputMu.Unlock()
+	if sink == nil {
+		return nil
+	}
+	return sink.close()
+}
+
+//Writes the queued async messages and commits the log files to stable storage with File.Sync, for
+//durability after critical events. The output set with SetOutput is synced too when it is a regular
+//file or has a Sync method; other writers are skipped. Returns the first error.
+func (c *Configuration) Sync() error {
+	c.waitAsync()
+	output := c.GetOutput()
+	c.outputMu.Lock()
+	defer c.outputMu.Unlock()
+	var first error
+	commit := func(s interface{ Sync() error }) {
+		if err := s.Sync(); err != nil && first == nil {
+			first = err
+		}
+	}
+	if file, ok := output.(*os.File); ok {
+		if info, err := file.Stat(); err == nil && info.Mode().IsRegular() {
+			commit(file)
+		}
+	} else if s, ok := output.(interface{ Sync() error }); ok {
+		commit(s)
+	}
+	if c.logFile != nil {
+		commit(c.logFile.file)
+	}
+	for _, sink := range c.fileSinks {
+		commit(sink.file)
+	}
+	return first
+}
+
+//Writes the queued async messages and commits the log files to stable storage with File.Sync
+func Sync() error {
+	return configuration.Sync()
+}
+
+//Starts a new log file every interval, named after the SetLogFile path plus the start of the interval
+//(app-2024-01-02.log for daily rotation of app.log). Zero disables rotation.
+func (c *Configuration) SetRotationInterval(interval time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.rotationInterval = interval
+}
+
+func (c *Configuration) GetRotationInterval() time.Duration {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.rotationInterval
+}
+
+//When enabled, files opened afterwards by SetLogFile, AddFileSink and Logger.SetFile get their missing
+//parent directories created with mode 0755, e.g. on the first run in a fresh container. Off by default,
+//so a mistyped directory still fails instead of being created.
+func (c *Configuration) SetCreateDirs(create bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.createDirs = create
+}
+
+func (c *Configuration) GetCreateDirs() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.createDirs
+}
+
+//Logs the pending sampling summaries, writes every queued async message and stops the async
+//goroutine, closes the files opened by SetLogFile and AddFileSink and the connection opened by
+//SetSyslog, posts the pending webhook alerts for up to five seconds, writes and stops the console
+//buffer, then waits for the compression of rotated files. It is safe to call while other goroutines
+//log: files are swapped out under the lock writes hold, so once Close returns no write reaches a
+//closed file. The failures of all components are returned joined with errors.Join, each prefixed
+//with the component, e.g. "file sink error.log: ...".
+func (c *Configuration) Close() error {
+	c.flushSamples()
+	c.asyncMu.Lock()
+	stopped := c.stopAsync()
+	c.asyncMu.Unlock()
+	stopped.waitReported()
+	var errs []error
+	if err := c.SetLogFile(""); err != nil {
+		errs = append(errs, fmt.Errorf("log file: %w", err))
+	}
+	errs = append(errs, c.closeFileSinks())
+	if err := c.closeSyslog(); err != nil {
+		errs = append(errs, fmt.Errorf("syslog: %w", err))
+	}
+	if err := c.closeWebhook(); err != nil {
+		errs = append(errs, fmt.Errorf("webhook: %w", err))
+	}
+	if err := c.closeConsoleBuffer(); err != nil {
+		errs = append(errs, fmt.Errorf("console: %w", err))
+	}
+	c.compressing.Wait()
+	return errors.Join(errs...)
+}
+
+//Logs the pending sampling summaries, writes the queued async messages, closes the log files and the
+//syslog connection, posts the pending webhook alerts, writes the console buffer and waits for the
+//compression of rotated files. Returns the failures of all components joined.
+func Close() error {
+	return configuration.Close()
+}
+
+//Sets the time layout of file and output lines and of the time key of JSON records, e.g. time.RFC3339Nano.
+//An empty layout restores DefaultTimeFormat for lines and RFC 3339 with nanoseconds for JSON.
+func (c *Configuration) SetTimeFormat(layout string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.timeFormat = layout
+}
+
+func (c *Configuration) GetTimeFormat() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.timeFormat == "" {
+		return DefaultTimeFormat
+	}
+	return c.timeFormat
+}
+
+//Returns the time layout of JSON records, time.RFC3339Nano unless SetTimeFormat was used
+func (c *Configuration) jsonTimeFormat() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.timeFormat == "" {
+		return time.RFC3339Nano
+	}
+	return c.timeFormat
+}
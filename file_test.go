@@ -0,0 +1,507 @@
+// Package orchid
+//Copyright (c) 2022 Epiphyte LLC. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+// Author: Fernandez-Alcon, Jose
+// e-mail: jose@epiphyte.io
+package orchid
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSetLogFile(t *testing.T) {
+	config := GetConfiguration()
+	defer config.Close()
+	path := filepath.Join(t.TempDir(), "app.log")
+
+	if err := config.SetLogFile(path); err != nil {
+		t.Fatal(err)
+	}
+	if config.GetLogFile() != path {
+		t.Errorf("expected %s, got %s", path, config.GetLogFile())
+	}
+	logger := New("TestFile")
+	captureConsole(t, func() { logger.Info("to the file") })
+	if err := Close(); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "TestFile") || !strings.HasSuffix(string(data), "to the file\n") {
+		t.Errorf("unexpected file contents %q", data)
+	}
+	if config.GetLogFile() != "" {
+		t.Errorf("Close should release the file, got %s", config.GetLogFile())
+	}
+}
+
+func TestRotationInterval(t *testing.T) {
+	config := GetConfiguration()
+	defer config.Close()
+	defer config.SetRotationInterval(0)
+	dir := t.TempDir()
+
+	config.SetRotationInterval(24 * time.Hour)
+	if err := config.SetLogFile(filepath.Join(dir, "app.log")); err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range []logMessage{
+		{Severity: "INFO", Module: "Rotation", Text: "first day", Time: time.Date(2024, 1, 1, 12, 0, 0, 0, time.Local)},
+		{Severity: "INFO", Module: "Rotation", Text: "still first day", Time: time.Date(2024, 1, 1, 23, 59, 59, 0, time.Local)},
+		{Severity: "INFO", Module: "Rotation", Text: "second day", Time: time.Date(2024, 1, 2, 0, 0, 0, 0, time.Local)},
+	} {
+		if err := m.writeToFile(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := filepath.Base(config.GetLogFile()); got != "app-2024-01-02.log" {
+		t.Errorf("expected the second day's file to be open, got %s", got)
+	}
+	config.Close()
+
+	first, _ := os.ReadFile(filepath.Join(dir, "app-2024-01-01.log"))
+	second, _ := os.ReadFile(filepath.Join(dir, "app-2024-01-02.log"))
+	if strings.Count(string(first), "\n") != 2 || !strings.Contains(string(first), "still first day") {
+		t.Errorf("unexpected first day contents %q", first)
+	}
+	if strings.Count(string(second), "\n") != 1 || !strings.Contains(string(second), "second day") {
+		t.Errorf("unexpected second day contents %q", second)
+	}
+
+	if got := rotatedPath("logs/app.log", time.Date(2024, 1, 2, 15, 0, 0, 0, time.Local), time.Hour); got != "logs/app-2024-01-02T15-00-00.log" {
+		t.Errorf("unexpected hourly file name %s", got)
+	}
+}
+
+func TestTimeFormat(t *testing.T) {
+	config := GetConfiguration()
+	defer config.SetTimeFormat("")
+	output := captureOutput(t)
+	m := logMessage{Severity: "INFO", Module: "Time", Text: "formatted", Time: time.Date(2024, 1, 2, 3, 4, 5, 123456789, time.UTC)}
+
+	m.writeToFile()
+	config.SetTimeFormat(time.RFC3339Nano)
+	m.writeToFile()
+	config.SetTimeFormat("")
+	m.writeToFile()
+
+	lines := strings.Split(output.String(), "\n")
+	for i, prefix := range []string{"2024-01-02 03:04:05 Time", "2024-01-02T03:04:05.123456789Z Time", "2024-01-02 03:04:05 Time"} {
+		if !strings.HasPrefix(lines[i], prefix) {
+			t.Errorf("line %d: expected prefix %q, got %q", i, prefix, lines[i])
+		}
+	}
+}
+
+func TestAddFileSink(t *testing.T) {
+	config := GetConfiguration()
+	defer config.Close()
+	dir := t.TempDir()
+	appLog, errorLog := filepath.Join(dir, "app.log"), filepath.Join(dir, "error.log")
+
+	if err := config.AddFileSink(appLog, FormatText, DebugLevel); err != nil {
+		t.Fatal(err)
+	}
+	if err := config.AddFileSink(errorLog, FormatJSON, WarnLevel); err != nil {
+		t.Fatal(err)
+	}
+	logger := New("TestSinks")
+	captureConsole(t, func() {
+		logger.Info("routine")
+		logger.Warn("worrying")
+	})
+	if err := config.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	all, err := os.ReadFile(appLog)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(all), "routine") || !strings.Contains(string(all), "worrying") {
+		t.Errorf("app.log should have every message, got %q", all)
+	}
+	errors, err := os.ReadFile(errorLog)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var record map[string]interface{}
+	if err := json.Unmarshal(errors, &record); err != nil {
+		t.Fatalf("error.log should have a single JSON line: %v in %q", err, errors)
+	}
+	if record["severity"] != "WARN" || record["text"] != "worrying" {
+		t.Errorf("error.log should only have WARN and above, got %q", errors)
+	}
+	if strings.HasPrefix(string(all), "{") {
+		t.Errorf("app.log should stay in the text format, got %q", all)
+	}
+
+	captureConsole(t, func() { logger.Warn("after close") })
+	if data, _ := os.ReadFile(errorLog); strings.Contains(string(data), "after close") {
+		t.Errorf("Close should remove the sinks, got %q", data)
+	}
+}
+
+func TestTimestampCache(t *testing.T) {
+	var cache timestampCache
+	base := time.Date(2024, 1, 1, 23, 59, 59, 0, time.Local)
+	for _, layout := range []string{DefaultTimeFormat, time.RFC3339, time.RFC3339Nano, "15:04:05.000", "15:04:05,999"} {
+		for _, offset := range []time.Duration{0, 400 * time.Millisecond, 999 * time.Millisecond, time.Second, 1500 * time.Millisecond} {
+			now := base.Add(offset)
+			if got, want := cache.format(now, layout), now.Format(layout); got != want {
+				t.Errorf("format(%v, %q) = %q, want %q", now, layout, got, want)
+			}
+			if got, want := cache.format(now.UTC(), layout), now.UTC().Format(layout); got != want {
+				t.Errorf("format(%v, %q) = %q, want %q", now.UTC(), layout, got, want)
+			}
+		}
+	}
+}
+
+func BenchmarkTimestampFormat(b *testing.B) {
+	now := time.Now()
+	b.Run("naive", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_ = now.Add(time.Duration(i) * time.Microsecond).Format(DefaultTimeFormat)
+		}
+	})
+	b.Run("cached", func(b *testing.B) {
+		var cache timestampCache
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_ = cache.format(now.Add(time.Duration(i)*time.Microsecond), DefaultTimeFormat)
+		}
+	})
+}
+
+func TestLoggerSetFile(t *testing.T) {
+	config := GetConfiguration()
+	defer config.Close()
+	dir := t.TempDir()
+	appLog, auditLog := filepath.Join(dir, "app.log"), filepath.Join(dir, "audit.log")
+
+	if err := config.SetLogFile(appLog); err != nil {
+		t.Fatal(err)
+	}
+	audit := New("audit")
+	child := audit.With("user", "alice")
+	if err := audit.SetFile(auditLog, FormatJSON); err != nil {
+		t.Fatal(err)
+	}
+	if audit.GetFile() != auditLog {
+		t.Errorf("expected %s, got %s", auditLog, audit.GetFile())
+	}
+	captureConsole(t, func() {
+		New("app").Info("request served")
+		child.Info("permission granted")
+	})
+	if err := config.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if audit.GetFile() != auditLog {
+		t.Errorf("the global Close should leave the logger's file open, got %q", audit.GetFile())
+	}
+	if err := config.SetLogFile(appLog); err != nil {
+		t.Fatal(err)
+	}
+	if err := audit.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if audit.GetFile() != "" || config.GetLogFile() != appLog {
+		t.Errorf("Logger.Close should only close the logger's file, got %q and %q", audit.GetFile(), config.GetLogFile())
+	}
+	captureConsole(t, func() { audit.Info("back to the global file") })
+	if err := config.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	app, _ := os.ReadFile(appLog)
+	if !strings.Contains(string(app), "request served") || strings.Contains(string(app), "permission granted") {
+		t.Errorf("app.log should only have the other loggers, got %q", app)
+	}
+	if !strings.Contains(string(app), "back to the global file") {
+		t.Errorf("Logger.Close should restore the global file, got %q", app)
+	}
+	audited, _ := os.ReadFile(auditLog)
+	var record map[string]interface{}
+	if err := json.Unmarshal(audited, &record); err != nil {
+		t.Fatalf("audit.log should have a single JSON line: %v in %q", err, audited)
+	}
+	if record["text"] != "permission granted" || record["user"] != "alice" || record["module"] != "audit" {
+		t.Errorf("audit.log should only have the audit logger, got %q", audited)
+	}
+	if strings.HasPrefix(string(app), "{") {
+		t.Errorf("app.log should stay in the text format, got %q", app)
+	}
+}
+
+func TestConcurrentCloseAndLog(t *testing.T) {
+	config := GetConfiguration()
+	defer config.Close()
+	defer config.SetErrorHandler(nil)
+	dir := t.TempDir()
+
+	var mu sync.Mutex
+	var failures []error
+	config.SetErrorHandler(func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		failures = append(failures, err)
+	})
+	logger := New("TestConcurrentClose")
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	captureConsole(t, func() {
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for {
+					select {
+					case <-stop:
+						return
+					default:
+						logger.Info("racing Close")
+					}
+				}
+			}()
+		}
+		for i := 0; i < 50; i++ {
+			if err := config.SetLogFile(filepath.Join(dir, "app.log")); err != nil {
+				t.Error(err)
+			}
+			if err := config.Close(); err != nil {
+				t.Error(err)
+			}
+		}
+		close(stop)
+		wg.Wait()
+	})
+
+	if len(failures) != 0 {
+		t.Errorf("writes should never reach a closed file, got %v", failures[0])
+	}
+}
+
+func TestCompressRotated(t *testing.T) {
+	config := GetConfiguration()
+	defer config.Close()
+	defer config.SetRotationInterval(0)
+	defer config.SetCompressRotated(false)
+	dir := t.TempDir()
+	firstDay := filepath.Join(dir, "app-2024-01-01.log")
+
+	//A .gz left by an earlier run gets the new data appended as another gzip member
+	var previous bytes.Buffer
+	zw := gzip.NewWriter(&previous)
+	zw.Write([]byte("from an earlier run\n"))
+	zw.Close()
+	if err := os.WriteFile(firstDay+".gz", previous.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	config.SetRotationInterval(24 * time.Hour)
+	config.SetCompressRotated(true)
+	if err := config.SetLogFile(filepath.Join(dir, "app.log")); err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range []logMessage{
+		{Severity: "INFO", Module: "Compress", Text: "first day", Time: time.Date(2024, 1, 1, 12, 0, 0, 0, time.Local)},
+		{Severity: "INFO", Module: "Compress", Text: "second day", Time: time.Date(2024, 1, 2, 0, 0, 0, 0, time.Local)},
+	} {
+		if err := m.writeToFile(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := config.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Stat(firstDay); !os.IsNotExist(err) {
+		t.Errorf("the rotated file should be removed, got %v", err)
+	}
+	compressed, err := os.Open(firstDay + ".gz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer compressed.Close()
+	zr, err := gzip.NewReader(compressed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(data), "from an earlier run\n") || !strings.HasSuffix(string(data), "first day\n") {
+		t.Errorf("unexpected compressed contents %q", data)
+	}
+	if second, _ := os.ReadFile(filepath.Join(dir, "app-2024-01-02.log")); !strings.Contains(string(second), "second day") {
+		t.Errorf("the open file should not be compressed, got %q", second)
+	}
+}
+
+func TestCompressRotatedSameName(t *testing.T) {
+	config := GetConfiguration()
+	defer config.Close()
+	defer config.SetRotationInterval(0)
+	defer config.SetCompressRotated(false)
+	dir := t.TempDir()
+
+	config.SetRotationInterval(24 * time.Hour)
+	config.SetCompressRotated(true)
+	if err := config.SetLogFile(filepath.Join(dir, "app.log")); err != nil {
+		t.Fatal(err)
+	}
+	//Both periods start on 2024-01-01, in different locations, so they share the file name
+	est := time.FixedZone("EST", -5*60*60)
+	for _, m := range []logMessage{
+		{Severity: "INFO", Module: "Compress", Text: "in UTC", Time: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)},
+		{Severity: "INFO", Module: "Compress", Text: "in EST", Time: time.Date(2024, 1, 1, 12, 0, 0, 0, est)},
+	} {
+		if err := m.writeToFile(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := config.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "app-2024-01-01.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "in UTC") || !strings.Contains(string(data), "in EST") {
+		t.Errorf("both lines should stay in the open file, got %q", data)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "app-2024-01-01.log.gz")); !os.IsNotExist(err) {
+		t.Errorf("the open file should not be compressed, got %v", err)
+	}
+}
+
+func TestCloseJoinsErrors(t *testing.T) {
+	config := GetConfiguration().Clone()
+	dir := t.TempDir()
+	first, second := filepath.Join(dir, "first.log"), filepath.Join(dir, "second.log")
+	for _, path := range []string{first, second} {
+		if err := config.AddFileSink(path, FormatText, DebugLevel); err != nil {
+			t.Fatal(err)
+		}
+	}
+	//Closing the handles behind orchid's back makes both sinks fail to close
+	for _, sink := range config.fileSinks {
+		sink.file.Close()
+	}
+
+	err := config.Close()
+	if !errors.Is(err, os.ErrClosed) {
+		t.Fatalf("expected the close errors, got %v", err)
+	}
+	for _, path := range []string{first, second} {
+		if !strings.Contains(err.Error(), "file sink "+path+": ") {
+			t.Errorf("expected the failure of %s, got %q", path, err)
+		}
+	}
+}
+
+type syncWriter struct {
+	bytes.Buffer
+	synced int
+}
+
+func (w *syncWriter) Sync() error {
+	w.synced++
+	return nil
+}
+
+func TestSync(t *testing.T) {
+	config := GetConfiguration()
+	defer config.Close()
+	defer config.SetOutput(nil)
+	dir := t.TempDir()
+
+	if err := Sync(); err != nil {
+		t.Errorf("Sync without files should be a no-op, got %v", err)
+	}
+	config.SetOutput(&bytes.Buffer{})
+	if err := Sync(); err != nil {
+		t.Errorf("Sync should skip writers that cannot sync, got %v", err)
+	}
+	var w syncWriter
+	config.SetOutput(&w)
+	if err := config.AddFileSink(filepath.Join(dir, "error.log"), FormatText, WarnLevel); err != nil {
+		t.Fatal(err)
+	}
+	if err := config.SetLogFile(filepath.Join(dir, "app.log")); err != nil {
+		t.Fatal(err)
+	}
+	captureConsole(t, func() { New("TestSync").Warn("critical event") })
+	if err := Sync(); err != nil {
+		t.Errorf("Sync failed: %v", err)
+	}
+	if w.synced != 1 {
+		t.Errorf("expected the output's Sync to be called once, got %d", w.synced)
+	}
+}
+
+func TestCreateDirs(t *testing.T) {
+	config := GetConfiguration().Clone()
+	defer config.Close()
+	path := filepath.Join(t.TempDir(), "logs", "app", "app.log")
+
+	if err := config.SetLogFile(path); err == nil {
+		t.Fatal("a missing directory should fail by default")
+	}
+
+	config.SetCreateDirs(true)
+	if err := config.SetLogFile(path); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("expected the file and its directories to be created: %v", err)
+	}
+
+	config.SetRotationInterval(24 * time.Hour)
+	rotated := filepath.Join(filepath.Dir(path), "daily", "app.log")
+	if err := config.AddFileSink(rotated, FormatText, DebugLevel); err != nil {
+		t.Errorf("rotated files should get their directories created too: %v", err)
+	}
+}
+
+func TestRotationPeriodDST(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip(err)
+	}
+	//Clocks go back from 02:00 EDT to 01:00 EST on 2026-11-01, so the day has 25 hours
+	midnight := time.Date(2026, 11, 1, 0, 0, 0, 0, newYork)
+	for _, at := range []time.Time{
+		time.Date(2026, 11, 1, 0, 30, 0, 0, newYork),
+		time.Date(2026, 11, 1, 1, 30, 0, 0, newYork),
+		time.Date(2026, 11, 1, 23, 59, 0, 0, newYork),
+	} {
+		if period := rotationPeriod(at, 24*time.Hour); !period.Equal(midnight) {
+			t.Errorf("%v: expected the period to start at %v, got %v", at, midnight, period)
+		}
+	}
+	if period := rotationPeriod(time.Date(2026, 11, 2, 0, 0, 0, 0, newYork), 24*time.Hour); period.Equal(midnight) {
+		t.Error("the next calendar day should start a new period")
+	}
+	if period := rotationPeriod(time.Date(2026, 11, 1, 15, 45, 0, 0, newYork), time.Hour); period.Hour() != 15 || period.Minute() != 0 {
+		t.Errorf("hourly periods should follow the wall clock, got %v", period)
+	}
+}
@@ -0,0 +1,301 @@
+// Package orchid
+//Copyright (c) 2022 Epiphyte LLC. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+// Author: Fernandez-Alcon, Jose
+// e-mail: jose@epiphyte.io
+package orchid
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+//Describes how messages are rendered on the console
+type Format int
+
+const (
+	FormatText Format = iota //Aligned module and severity columns followed by the text, colored on terminals
+	FormatJSON               //One JSON object per line, never colored
+)
+
+//Describes a message rendered as JSON. The fields are written as top-level keys after the others.
+type jsonRecord struct {
+	V        int      `json:"v,omitempty"`
+	Time     string   `json:"time"`
+	Severity string   `json:"severity"`
+	Module   string   `json:"module"`
+	Text     string   `json:"text"`
+	Type     string   `json:"type,omitempty"`
+	Caller   string   `json:"caller,omitempty"`
+	ID       string   `json:"id,omitempty"`
+	UptimeMS int64    `json:"uptime_ms,omitempty"`
+	Stack    []string `json:"stack,omitempty"`
+	Fields   []Field  `json:"-"`
+
+	stringifyInts bool              //Whether integer fields beyond 2^53 are written as strings, see SetStringifyLargeInts
+	names         map[string]string //The keys renamed with SetJSONFieldNames
+}
+
+//Keys of jsonRecord that fields cannot take over. A field with one of these keys is written as field_<key>.
+var reservedJSONKeys = map[string]bool{
+	"v": true, "time": true, "severity": true, "module": true, "text": true,
+	"type": true, "caller": true, "id": true, "uptime_ms": true, "stack": true,
+}
+
+func (l *logMessage) jsonRecord() jsonRecord {
+	c := l.conf()
+	return jsonRecord{
+		V:        c.GetSchemaVersion(),
+		Time:     l.Time.Format(c.jsonTimeFormat()),
+		Severity: l.Severity,
+		Module:   l.Module,
+		Text:     l.fullText(),
+		Type:     l.Type,
+		Caller:   l.caller(),
+		ID:       l.ID,
+		UptimeMS: l.Uptime.Milliseconds(),
+		Stack:    l.Stack,
+		Fields:   l.Fields,
+
+		stringifyInts: c.GetStringifyLargeInts(),
+		names:         c.jsonFieldNames(),
+	}
+}
+
+//Writes the record keys followed by one top-level key per field. When a key is repeated the last value wins,
+//at the position the key first appeared.
+func (r jsonRecord) MarshalJSON() ([]byte, error) {
+	type plain jsonRecord
+	data, err := json.Marshal(plain(r))
+	if err != nil {
+		return data, err
+	}
+	if len(r.names) > 0 {
+		data = renameJSONKeys(data, r.names)
+	}
+	if len(r.Fields) == 0 {
+		return data, nil
+	}
+	var keys []string
+	values := make(map[string]interface{}, len(r.Fields))
+	for _, f := range r.Fields {
+		key := f.Key
+		if reservedJSONKeys[key] || r.renamedTo(key) {
+			key = "field_" + key
+		}
+		if _, ok := values[key]; !ok {
+			keys = append(keys, key)
+		}
+		values[key] = f.Value
+	}
+	buf := bytes.NewBuffer(data[: len(data)-1 : len(data)-1])
+	for _, key := range keys {
+		name, _ := json.Marshal(key)
+		buf.WriteByte(',')
+		buf.Write(name)
+		buf.WriteByte(':')
+		value := values[key]
+		if r.stringifyInts && largeInt(value) {
+			value = fmt.Sprint(value)
+		}
+		buf.Write(jsonValue(value))
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+//Reports whether SetJSONFieldNames gave a record key the name key
+func (r jsonRecord) renamedTo(key string) bool {
+	for _, name := range r.names {
+		if name == key {
+			return true
+		}
+	}
+	return false
+}
+
+//Returns the JSON object data with the top-level keys found in names renamed, in the same order
+func renameJSONKeys(data []byte, names map[string]string) []byte {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.Token()
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for dec.More() {
+		token, _ := dec.Token()
+		var value json.RawMessage
+		dec.Decode(&value)
+		key, _ := token.(string)
+		if name, ok := names[key]; ok {
+			key = name
+		}
+		if buf.Len() > 1 {
+			buf.WriteByte(',')
+		}
+		name, _ := json.Marshal(key)
+		buf.Write(name)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes()
+}
+
+//Returns the message as a single line JSON object, without the newline
+func (l *logMessage) jsonLine() []byte {
+	data, _ := json.Marshal(l.jsonRecord())
+	return data
+}
+
+//Returns the message as a JSON object for files, indented under SetJSONIndent, without the newline
+func (l *logMessage) jsonFileText() []byte {
+	indent := l.conf().GetJSONIndent()
+	if indent == "" {
+		return l.jsonLine()
+	}
+	data, _ := json.MarshalIndent(l.jsonRecord(), "", indent)
+	return data
+}
+
+//Returns value marshaled with its native JSON type. Errors that do not marshal themselves become their
+//message, and values encoding/json rejects, such as channels, become their fmt.Sprint text.
+func jsonValue(value interface{}) []byte {
+	if err, ok := value.(error); ok {
+		if _, marshaler := value.(json.Marshaler); !marshaler {
+			value = err.Error()
+		}
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		data, _ = json.Marshal(fmt.Sprint(value))
+	}
+	return data
+}
+
+//The largest magnitude JavaScript numbers hold exactly, 2^53
+const maxSafeJSONInt = 1 << 53
+
+//Reports whether value is an integer JavaScript would round
+func largeInt(value interface{}) bool {
+	switch v := value.(type) {
+	case int:
+		return v > maxSafeJSONInt || v < -maxSafeJSONInt
+	case int64:
+		return v > maxSafeJSONInt || v < -maxSafeJSONInt
+	case uint:
+		return v > maxSafeJSONInt
+	case uint64:
+		return v > maxSafeJSONInt
+	}
+	return false
+}
+
+//Sets how messages are rendered on the console, independently of files. FormatJSON prints one JSON
+//object per line with time, severity, module, text and the optional v, type, caller, id, uptime_ms and
+//stack keys, followed by one key per field, for containers whose stdout is scraped as JSON. It is
+//never colored and has no log prefix.
+func (c *Configuration) SetConsoleFormat(format Format) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.consoleFormat = format
+}
+
+func (c *Configuration) GetConsoleFormat() Format {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.consoleFormat
+}
+
+//Sets the version stamped on JSON records as the v key, so pipelines validating records against a
+//schema can tell record shapes apart after upgrades. Zero, the default, leaves the key out.
+//Text lines are not affected.
+func (c *Configuration) SetSchemaVersion(version int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.schemaVersion = version
+}
+
+func (c *Configuration) GetSchemaVersion() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.schemaVersion
+}
+
+//Indents the JSON records written to files with indent, e.g. "  ", to read them while debugging.
+//Each record still ends with a newline. The console keeps single-line records, and an empty indent,
+//the default, restores single-line records in files for log shippers.
+func (c *Configuration) SetJSONIndent(indent string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.jsonIndent = indent
+}
+
+func (c *Configuration) GetJSONIndent() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.jsonIndent
+}
+
+//The record keys SetJSONFieldNames can rename
+var renamableJSONKeys = map[string]bool{"time": true, "severity": true, "module": true, "text": true}
+
+//Renames the time, severity, module and text keys of JSON records, e.g. for an ingestion pipeline
+//expecting other names:
+//
+//	config.SetJSONFieldNames(map[string]string{"time": "ts", "severity": "level", "text": "msg"})
+//
+//Keys left out keep their name, and fields named like a renamed key are written as field_<name>. Other
+//keys fail with ErrUnknownJSONField, and a name that is empty or already taken by another key fails too.
+//A nil or empty map restores the default names.
+func (c *Configuration) SetJSONFieldNames(names map[string]string) error {
+	taken := make(map[string]string, len(names))
+	for key, name := range names {
+		if !renamableJSONKeys[key] {
+			return fmt.Errorf("%w %q", ErrUnknownJSONField, key)
+		}
+		if name == "" || (reservedJSONKeys[name] && name != key && names[name] == "") || taken[name] != "" {
+			return fmt.Errorf("invalid JSON field name %q for %s", name, key)
+		}
+		taken[name] = key
+	}
+	copied := make(map[string]string, len(names))
+	for key, name := range names {
+		copied[key] = name
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.jsonNames = copied
+	return nil
+}
+
+//Returns a copy of the names set with SetJSONFieldNames, keyed by the default names
+func (c *Configuration) GetJSONFieldNames() map[string]string {
+	names := c.jsonFieldNames()
+	copied := make(map[string]string, len(names))
+	for key, name := range names {
+		copied[key] = name
+	}
+	return copied
+}
+
+//Returns the names set with SetJSONFieldNames, which must not be changed
+func (c *Configuration) jsonFieldNames() map[string]string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.jsonNames
+}
+
+//When enabled JSON records write integer fields beyond 2^53, such as 64-bit ids, as strings, so
+//JavaScript-based log viewers do not round them. Off by default. Text lines are not affected.
+func (c *Configuration) SetStringifyLargeInts(enabled bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.stringifyInts = enabled
+}
+
+func (c *Configuration) GetStringifyLargeInts() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.stringifyInts
+}
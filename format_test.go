@@ -0,0 +1,238 @@
+// Package orchid
+//Copyright (c) 2022 Epiphyte LLC. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+// Author: Fernandez-Alcon, Jose
+// e-mail: jose@epiphyte.io
+package orchid
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestConsoleFormatJSON(t *testing.T) {
+	config := GetConfiguration()
+	defer config.SetConsoleFormat(FormatText)
+	defer config.SetColorMode(config.GetColorMode())
+	output := captureOutput(t)
+
+	config.SetColorMode(ColorAlways)
+	config.SetConsoleFormat(FormatJSON)
+	console := captureConsole(t, func() {
+		New("TestJSON").With("attempt", 2).Error("upload failed", Err(errors.New("timeout")))
+	})
+
+	if strings.Contains(console, "\033[") {
+		t.Errorf("JSON lines should never be colored, got %q", console)
+	}
+	if strings.Count(console, "\n") != 1 {
+		t.Fatalf("expected a single line, got %q", console)
+	}
+	var record map[string]interface{}
+	if err := json.Unmarshal([]byte(console), &record); err != nil {
+		t.Fatalf("the console line should be JSON: %v in %q", err, console)
+	}
+	if record["severity"] != "ERROR" || record["module"] != "TestJSON" || record["text"] != "upload failed" ||
+		record["attempt"] != float64(2) || record["error"] != "timeout" {
+		t.Errorf("unexpected record %v", record)
+	}
+	if !strings.Contains(output.String(), "upload failed attempt=2 error=timeout") {
+		t.Errorf("the output should keep the text format, got %q", output.String())
+	}
+}
+
+func TestSchemaVersionAndType(t *testing.T) {
+	config := GetConfiguration()
+	defer config.SetConsoleFormat(FormatText)
+	defer config.SetSchemaVersion(0)
+	output := captureOutput(t)
+	logger := New("TestSchema").With("user", "jose")
+
+	config.SetConsoleFormat(FormatJSON)
+	console := captureConsole(t, func() { logger.Info("plain") })
+	if strings.Contains(console, `"v"`) || strings.Contains(console, `"type"`) {
+		t.Errorf("v and type should be left out by default, got %q", console)
+	}
+
+	config.SetSchemaVersion(2)
+	console = captureConsole(t, func() { logger.WithType("login").Info("signed in") })
+	var record map[string]interface{}
+	if err := json.Unmarshal([]byte(console), &record); err != nil {
+		t.Fatalf("the console line should be JSON: %v in %q", err, console)
+	}
+	if record["v"] != float64(2) || record["type"] != "login" || record["user"] != "jose" {
+		t.Errorf("expected the version, type and inherited fields, got %v", record)
+	}
+	if !strings.HasPrefix(console, `{"v":2,`) {
+		t.Errorf("the version should lead the record, got %q", console)
+	}
+	if strings.Contains(output.String(), "login") || strings.Contains(output.String(), "v=2") {
+		t.Errorf("text lines should not show the version or type, got %q", output.String())
+	}
+}
+
+type jsonPoint struct{ X, Y int }
+
+type upperText string
+
+func (u upperText) MarshalJSON() ([]byte, error) {
+	return json.Marshal(strings.ToUpper(string(u)))
+}
+
+func TestJSONFieldTypes(t *testing.T) {
+	config := GetConfiguration()
+	defer config.SetConsoleFormat(FormatText)
+	captureOutput(t)
+	config.SetConsoleFormat(FormatJSON)
+
+	console := captureConsole(t, func() {
+		New("TestJSONTypes").Info("typed",
+			Any("tags", []string{"a", "b"}),
+			Any("point", jsonPoint{1, 2}),
+			Any("counts", map[string]int{"ok": 3}),
+			Any("custom", upperText("shout")),
+			Any("channel", make(chan int)),
+			Err(errors.New("boom")),
+			String("text", "collides"),
+			String("tags", "last wins"),
+		)
+	})
+	var record map[string]interface{}
+	if err := json.Unmarshal([]byte(console), &record); err != nil {
+		t.Fatalf("the console line should be JSON: %v in %q", err, console)
+	}
+	if record["text"] != "typed" || record["field_text"] != "collides" {
+		t.Errorf("fields should not take over the record keys, got %v", record)
+	}
+	if point, _ := record["point"].(map[string]interface{}); point["X"] != float64(1) || point["Y"] != float64(2) {
+		t.Errorf("structs should keep their JSON shape, got %v", record["point"])
+	}
+	if counts, _ := record["counts"].(map[string]interface{}); counts["ok"] != float64(3) {
+		t.Errorf("maps should keep their JSON shape, got %v", record["counts"])
+	}
+	if record["custom"] != "SHOUT" || record["error"] != "boom" || record["tags"] != "last wins" {
+		t.Errorf("unexpected marshaler, error or repeated key values in %v", record)
+	}
+	if channel, _ := record["channel"].(string); !strings.HasPrefix(channel, "0x") {
+		t.Errorf("values JSON cannot encode should fall back to text, got %v", record["channel"])
+	}
+	if strings.Index(console, `"tags"`) > strings.Index(console, `"point"`) {
+		t.Errorf("a repeated key should keep its first position, got %q", console)
+	}
+}
+
+func TestJSONTimeFormat(t *testing.T) {
+	config := GetConfiguration()
+	defer config.SetConsoleFormat(FormatText)
+	defer config.SetTimeFormat("")
+	captureOutput(t)
+	config.SetConsoleFormat(FormatJSON)
+
+	timeOf := func() string {
+		var record struct {
+			Time string `json:"time"`
+		}
+		console := captureConsole(t, func() { New("TestJSONTime").Info("timed") })
+		if err := json.Unmarshal([]byte(console), &record); err != nil {
+			t.Fatalf("the console line should be JSON: %v in %q", err, console)
+		}
+		return record.Time
+	}
+	if _, err := time.Parse(time.RFC3339Nano, timeOf()); err != nil {
+		t.Errorf("expected RFC 3339 by default: %v", err)
+	}
+	config.SetTimeFormat("2006-01-02 15:04:05.000")
+	if _, err := time.Parse("2006-01-02 15:04:05.000", timeOf()); err != nil {
+		t.Errorf("expected the configured layout: %v", err)
+	}
+}
+
+func TestStringifyLargeInts(t *testing.T) {
+	config := GetConfiguration().Clone()
+	config.SetConsoleFormat(FormatJSON)
+	logger := config.New("TestLargeInts")
+	line := func() string {
+		return captureConsole(t, func() { logger.Info("ids", Int64("n", math.MaxInt64), Int("small", 42)) })
+	}
+
+	if console := line(); !strings.Contains(console, `"n":9223372036854775807,"small":42`) {
+		t.Errorf("integers should be numbers by default, got %q", console)
+	}
+	config.SetStringifyLargeInts(true)
+	if console := line(); !strings.Contains(console, `"n":"9223372036854775807","small":42`) {
+		t.Errorf("integers beyond 2^53 should be strings when enabled, got %q", console)
+	}
+}
+
+func TestJSONFieldNames(t *testing.T) {
+	config := GetConfiguration().Clone()
+	config.SetConsoleFormat(FormatJSON)
+	if err := config.SetJSONFieldNames(map[string]string{"Severity": "level"}); !errors.Is(err, ErrUnknownJSONField) {
+		t.Errorf("expected ErrUnknownJSONField, got %v", err)
+	}
+	if err := config.SetJSONFieldNames(map[string]string{"text": "module"}); err == nil {
+		t.Error("a name taken by another key should fail")
+	}
+	if err := config.SetJSONFieldNames(map[string]string{"time": "ts", "severity": "level", "text": "msg"}); err != nil {
+		t.Fatal(err)
+	}
+
+	console := captureConsole(t, func() { config.New("TestNames").Warn("renamed", String("msg", "collides")) })
+	var record map[string]interface{}
+	if err := json.Unmarshal([]byte(console), &record); err != nil {
+		t.Fatalf("the console line should be JSON: %v in %q", err, console)
+	}
+	if record["level"] != "WARN" || record["msg"] != "renamed" || record["module"] != "TestNames" || record["ts"] == nil {
+		t.Errorf("expected the renamed keys, got %v", record)
+	}
+	if record["severity"] != nil || record["text"] != nil || record["field_msg"] != "collides" {
+		t.Errorf("the default names should be gone and fields kept apart, got %v", record)
+	}
+	if !strings.HasPrefix(console, `{"ts":`) {
+		t.Errorf("renamed keys should keep their position, got %q", console)
+	}
+}
+
+func TestJSONIndent(t *testing.T) {
+	config := GetConfiguration().Clone()
+	config.SetConsoleFormat(FormatJSON)
+	path := filepath.Join(t.TempDir(), "app.json")
+	if err := config.AddFileSink(path, FormatJSON, DebugLevel); err != nil {
+		t.Fatal(err)
+	}
+	config.SetJSONIndent("  ")
+	logger := config.New("TestIndent")
+	console := captureConsole(t, func() {
+		logger.Info("first")
+		logger.Info("second")
+	})
+	if err := config.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Count(console, "\n") != 2 {
+		t.Errorf("the console should keep single-line records, got %q", console)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(data), "{\n  \"time\": ") || !strings.HasSuffix(string(data), "}\n") {
+		t.Errorf("expected indented records, got %q", data)
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	for _, text := range []string{"first", "second"} {
+		var record map[string]interface{}
+		if err := dec.Decode(&record); err != nil || record["text"] != text {
+			t.Errorf("expected the %s record to decode, got %v, %v", text, record, err)
+		}
+	}
+}
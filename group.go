@@ -0,0 +1,108 @@
+// Package orchid
+//Copyright (c) 2022 Epiphyte LLC. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+// Author: Fernandez-Alcon, Jose
+// e-mail: jose@epiphyte.io
+package orchid
+
+import (
+	"errors"
+	"sync"
+)
+
+//Describes the logger handed to a Group callback. Its lines are held until the callback returns.
+type GroupLogger struct {
+	mu       sync.Mutex
+	lg       *Logger
+	messages []logMessage
+	done     bool //Set when the callback returned, after which lines are rejected
+}
+
+//Runs fn and then writes every line it logged through g as one contiguous block, taking the write lock
+//once, so lines of other goroutines cannot interleave with a multi-line report:
+//
+//	logger.Group(func(g *orchid.GroupLogger) {
+//		g.Info("report for", day)
+//		g.Info("processed", count)
+//		g.Warn("skipped", skipped)
+//	})
+//
+//Hooks, filtering and redaction apply to each line as usual. Other logging inside fn is written right
+//away, before the group. g must not be used after fn returns; such lines are dropped and reported to
+//the error handler.
+func (lg *Logger) Group(fn func(g *GroupLogger)) {
+	g := &GroupLogger{lg: lg}
+	defer g.flush()
+	fn(g)
+}
+
+func (g *GroupLogger) log(severity string, a ...interface{}) {
+	c := g.lg.conf()
+	if !c.GetEnabled() {
+		return
+	}
+	var l logMessage
+	l.createLogMessage(g.lg, severity, a...)
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.done {
+		c.reportError("ORCHID GROUP ERROR:", errors.New("GroupLogger used after its callback returned: "+l.Text))
+		return
+	}
+	g.messages = append(g.messages, l)
+}
+
+//Writes the held lines under groupMu and closes the group
+func (g *GroupLogger) flush() {
+	g.mu.Lock()
+	messages := g.messages
+	g.messages, g.done = nil, true
+	g.mu.Unlock()
+
+	c := g.lg.conf()
+	ready := messages[:0]
+	for _, l := range messages {
+		if l.prepare(c) {
+			ready = append(ready, l)
+		}
+	}
+	type result struct {
+		printed bool
+		redraw  func()
+		err     error
+	}
+	results := make([]result, len(ready))
+	c.groupMu.Lock()
+	for i := range ready {
+		results[i].printed, results[i].redraw, results[i].err = ready[i].emit(c)
+	}
+	c.groupMu.Unlock()
+	for i, r := range results {
+		ready[i].finish(c, r.printed, r.redraw, r.err)
+	}
+}
+
+func (g *GroupLogger) Info(a ...interface{}) {
+	g.log("INFO", a...)
+}
+
+func (g *GroupLogger) OK(a ...interface{}) {
+	g.log("OK", a...)
+}
+
+func (g *GroupLogger) Error(a ...interface{}) {
+	g.log("ERROR", a...)
+}
+
+func (g *GroupLogger) Fatal(a ...interface{}) {
+	g.log("FATAL", a...)
+}
+
+func (g *GroupLogger) Warn(a ...interface{}) {
+	g.log("WARN", a...)
+}
+
+func (g *GroupLogger) Debug(a ...interface{}) {
+	g.log("DEBUG", a...)
+}
@@ -0,0 +1,75 @@
+// Package orchid
+//Copyright (c) 2022 Epiphyte LLC. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+// Author: Fernandez-Alcon, Jose
+// e-mail: jose@epiphyte.io
+package orchid
+
+import (
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestGroup(t *testing.T) {
+	config := GetConfiguration()
+	output := captureOutput(t)
+	logger := New("TestGroup")
+	noise := New("Noise")
+
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+	captureConsole(t, func() {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+					noise.Info("noise")
+				}
+			}
+		}()
+		logger.Group(func(g *GroupLogger) {
+			g.Info("report line 1")
+			noise.Info("inside")
+			g.Warn("report line 2")
+			g.Debug("report line 3")
+		})
+		close(stop)
+		wg.Wait()
+	})
+
+	lines := strings.Split(output.String(), "\n")
+	first := -1
+	for i, line := range lines {
+		if strings.Contains(line, "report line 1") {
+			first = i
+		}
+	}
+	if first < 0 || first+2 >= len(lines) {
+		t.Fatalf("expected the group lines, got %q", output.String())
+	}
+	for i, want := range []string{"INFO   report line 1", "WARN   report line 2", "DEBUG  report line 3"} {
+		if !strings.Contains(lines[first+i], want) {
+			t.Errorf("expected %q as line %d of the group, got %q", want, i+1, lines[first+i])
+		}
+	}
+	if strings.Index(output.String(), "inside") > strings.Index(output.String(), "report line 1") {
+		t.Error("logging outside the group should be written right away")
+	}
+
+	var reported error
+	config.SetErrorHandler(func(err error) { reported = err })
+	defer config.SetErrorHandler(nil)
+	var escaped *GroupLogger
+	logger.Group(func(g *GroupLogger) { escaped = g })
+	output.Reset()
+	escaped.Info("too late")
+	if output.Len() != 0 || reported == nil || !strings.Contains(reported.Error(), "too late") {
+		t.Errorf("a group used after its callback should drop the line and report it, got %q and %v", output.String(), reported)
+	}
+}
@@ -0,0 +1,68 @@
+// Package orchid
+//Copyright (c) 2022 Epiphyte LLC. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+// Author: Fernandez-Alcon, Jose
+// e-mail: jose@epiphyte.io
+package orchid
+
+import "time"
+
+//Describes a message as seen and changed by hooks
+type Entry struct {
+	Severity string    //The severity of the message, e.g. INFO or ERROR
+	Module   string    //The name of the module where the log was originated
+	Text     string    //The contents of the log
+	Time     time.Time //The time at which the log was created
+	Fields   []Field   //The key-value pairs of the message, a copy hooks may change
+}
+
+//Runs fn on every message before it is redacted and written anywhere, except to the audit mirror,
+//which receives the message as it was before the hooks.
+//The hook may change the entry, e.g. to add a hostname field, or return false to drop the message:
+//
+//	config.AddHook(func(e *orchid.Entry) bool {
+//		e.Fields = append(e.Fields, orchid.String("host", host))
+//		return !strings.HasPrefix(e.Text, "healthcheck")
+//	})
+//
+//Hooks run in the order they were added, and the first one returning false stops the rest.
+func (c *Configuration) AddHook(fn func(*Entry) bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.hooks = append(c.hooks, fn)
+}
+
+//Removes every hook added with AddHook
+func (c *Configuration) ClearHooks() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.hooks = nil
+}
+
+//Runs the hooks on the message and applies their changes. Returns false when a hook drops it.
+func (c *Configuration) runHooks(l *logMessage) bool {
+	c.mu.RLock()
+	hooks := c.hooks
+	c.mu.RUnlock()
+	if len(hooks) == 0 {
+		return true
+	}
+	e := Entry{
+		Severity: l.Severity,
+		Module:   l.Module,
+		Text:     l.Text,
+		Time:     l.Time,
+		Fields:   append([]Field(nil), l.Fields...),
+	}
+	for _, hook := range hooks {
+		if !hook(&e) {
+			return false
+		}
+	}
+	if level, err := ParseLevel(e.Severity); err == nil {
+		e.Severity = level.String()
+	}
+	l.Severity, l.Module, l.Text, l.Time, l.Fields = e.Severity, e.Module, e.Text, e.Time, e.Fields
+	return true
+}
@@ -0,0 +1,51 @@
+// Package orchid
+//Copyright (c) 2022 Epiphyte LLC. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+// Author: Fernandez-Alcon, Jose
+// e-mail: jose@epiphyte.io
+package orchid
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHooks(t *testing.T) {
+	config := GetConfiguration()
+	defer config.ClearHooks()
+	output := captureOutput(t)
+	logger := New("TestHooks").With("user", "alice")
+
+	var order []string
+	config.AddHook(func(e *Entry) bool {
+		order = append(order, "first")
+		e.Fields = append(e.Fields, String("host", "web-1"))
+		return !strings.HasPrefix(e.Text, "healthcheck")
+	})
+	config.AddHook(func(e *Entry) bool {
+		order = append(order, "second")
+		if e.Text == "slow request" {
+			e.Severity = "warning"
+		}
+		return true
+	})
+
+	captureConsole(t, func() {
+		logger.Info("healthcheck ok")
+		logger.Info("slow request")
+	})
+	out := output.String()
+	if strings.Contains(out, "healthcheck") {
+		t.Errorf("a hook returning false should drop the message, got %q", out)
+	}
+	if !strings.Contains(out, "WARN   slow request user=alice host=web-1") {
+		t.Errorf("expected the changes of the hooks, got %q", out)
+	}
+	if strings.Join(order, ",") != "first,first,second" {
+		t.Errorf("hooks should run in order and stop at a drop, ran %v", order)
+	}
+	if len(logger.fields) != 1 {
+		t.Errorf("hooks should not change the logger fields, got %v", logger.fields)
+	}
+}
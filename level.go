@@ -0,0 +1,94 @@
+// Package orchid
+//Copyright (c) 2022 Epiphyte LLC. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+// Author: Fernandez-Alcon, Jose
+// e-mail: jose@epiphyte.io
+package orchid
+
+import (
+	"fmt"
+	"strings"
+)
+
+//Describes the severity of a log message. Higher values are more severe.
+type Level int
+
+const (
+	DebugLevel Level = 10
+	InfoLevel  Level = 20
+	OKLevel    Level = 25
+	WarnLevel  Level = 30
+	ErrorLevel Level = 40
+	FatalLevel Level = 50
+)
+
+var levelNames = map[Level]string{
+	DebugLevel: "DEBUG",
+	InfoLevel:  "INFO",
+	OKLevel:    "OK",
+	WarnLevel:  "WARN",
+	ErrorLevel: "ERROR",
+	FatalLevel: "FATAL",
+}
+
+func (lv Level) String() string {
+	if name, ok := levelNames[lv]; ok {
+		return name
+	}
+	return fmt.Sprintf("LEVEL(%d)", int(lv))
+}
+
+//Returns the level matching name. Matching is case insensitive and WARNING is accepted as an alias of WARN.
+func ParseLevel(name string) (Level, error) {
+	upper := strings.ToUpper(strings.TrimSpace(name))
+	if upper == "WARNING" {
+		upper = "WARN"
+	}
+	for lv, levelName := range levelNames {
+		if levelName == upper {
+			return lv, nil
+		}
+	}
+	return 0, fmt.Errorf("%w %q", ErrUnknownLevel, name)
+}
+
+//Implements flag.Value so the minimum level can be set from the command line. The zero value sets the
+//global configuration:
+//
+//	flag.Var(orchid.NewLevelFlag(), "log-level", "minimum log level")
+type LevelFlagValue struct {
+	config *Configuration
+}
+
+func NewLevelFlag() *LevelFlagValue {
+	return &LevelFlagValue{config: GetConfiguration()}
+}
+
+func (f *LevelFlagValue) String() string {
+	if f == nil || f.config == nil {
+		return ""
+	}
+	return f.config.GetLevel().String()
+}
+
+func (f *LevelFlagValue) Set(value string) error {
+	level, err := ParseLevel(value)
+	if err != nil {
+		return err
+	}
+	f.conf().SetLevel(level)
+	return nil
+}
+
+func (f *LevelFlagValue) Get() interface{} {
+	return f.conf().GetLevel()
+}
+
+//Returns the configuration the flag sets, the global one for the zero value
+func (f *LevelFlagValue) conf() *Configuration {
+	if f.config == nil {
+		return GetConfiguration()
+	}
+	return f.config
+}
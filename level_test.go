@@ -0,0 +1,59 @@
+// Package orchid
+//Copyright (c) 2022 Epiphyte LLC. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+// Author: Fernandez-Alcon, Jose
+// e-mail: jose@epiphyte.io
+package orchid
+
+import (
+	"flag"
+	"testing"
+)
+
+func TestLevelFlagValue(t *testing.T) {
+	config := GetConfiguration()
+	defer config.SetLevel(config.GetLevel())
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.Var(NewLevelFlag(), "log-level", "minimum log level")
+
+	if err := fs.Parse([]string{"--log-level", "warning"}); err != nil {
+		t.Fatal(err)
+	}
+	if config.GetLevel() != WarnLevel {
+		t.Errorf("expected WARN, got %s", config.GetLevel())
+	}
+
+	f := NewLevelFlag()
+	for _, valid := range []string{"debug", "INFO", "Ok", "warn", "ERROR", "fatal"} {
+		if err := f.Set(valid); err != nil {
+			t.Errorf("Set(%q) returned %v", valid, err)
+		}
+	}
+	if f.String() != "FATAL" {
+		t.Errorf("expected String() to be FATAL, got %q", f.String())
+	}
+
+	for _, invalid := range []string{"", "verbose", "10"} {
+		if err := f.Set(invalid); err == nil {
+			t.Errorf("Set(%q) should fail", invalid)
+		}
+	}
+	if f.String() != "FATAL" {
+		t.Errorf("invalid input changed the level to %q", f.String())
+	}
+
+	var zero LevelFlagValue
+	if zero.String() != "" {
+		t.Errorf("zero value String() should be empty, got %q", zero.String())
+	}
+	fs = flag.NewFlagSet("zero", flag.ContinueOnError)
+	fs.Var(&zero, "log-level", "minimum log level")
+	if err := fs.Parse([]string{"--log-level", "error"}); err != nil {
+		t.Fatal(err)
+	}
+	if config.GetLevel() != ErrorLevel || zero.Get() != ErrorLevel {
+		t.Errorf("the zero value should set the global level, got %s", config.GetLevel())
+	}
+}
@@ -0,0 +1,395 @@
+// Package orchid
+//Copyright (c) 2022 Epiphyte LLC. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+// Author: Fernandez-Alcon, Jose
+// e-mail: jose@epiphyte.io
+package orchid
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"reflect"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+//Describes a logger bound to a module name. The package-level functions use a default Logger named by Init.
+type Logger struct {
+	mu          sync.RWMutex
+	module      string          //The name of the module the messages belong to
+	fields      []Field         //Key-value pairs added to every message, set with With
+	initialized bool            //Whether the module name was given by New or Init
+	config      *Configuration  //The configuration the logger follows, the global one when nil
+	file        *loggerFile     //The file set with SetFile, shared with the children from With
+	msgType     string          //The type of the messages in JSON, set with WithType
+	ctx         context.Context //The context set with WithContext, whose extracted fields every message carries
+}
+
+//Describes a key-value pair attached to a message. Fields passed to Info and the other level methods
+//are added to the message instead of its text:
+//
+//	logger.Info("request served", orchid.Int("status", 200), orchid.Duration("took", elapsed))
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+var defaultLogger = &Logger{module: "NO_NAME", file: &loggerFile{}}
+
+func New(module_name string) *Logger {
+	return &Logger{module: module_name, initialized: true, file: &loggerFile{}}
+}
+
+//Returns the configuration the logger follows
+func (lg *Logger) conf() *Configuration {
+	if lg.config != nil {
+		return lg.config
+	}
+	return configuration
+}
+
+//Sets the module name. Under SetStrictInit it fails if the logger was already named by New or Init.
+//Like SetModule, it rejects an empty name or one over MaxModuleLength bytes.
+func (lg *Logger) Init(module_name string) error {
+	return lg.setModule(module_name, lg.conf().GetStrictInit())
+}
+
+//Renames the logger, e.g. when a long-lived logger moves to a new context. Unlike Init it is
+//allowed under SetStrictInit, since renaming is its purpose. An empty name or one over MaxModuleLength
+//bytes is rejected.
+//Children already created with With keep the old name.
+func (lg *Logger) SetModule(module_name string) error {
+	return lg.setModule(module_name, false)
+}
+
+//The longest module name, in bytes, Init and SetModule accept
+const MaxModuleLength = 256
+
+func (lg *Logger) setModule(module_name string, strict bool) error {
+	if strings.TrimSpace(module_name) == "" {
+		return fmt.Errorf("%w %q", ErrEmptyModule, module_name)
+	}
+	if len(module_name) > MaxModuleLength {
+		return fmt.Errorf("%w: %d bytes, the limit is %d", ErrModuleTooLong, len(module_name), MaxModuleLength)
+	}
+	lg.mu.Lock()
+	defer lg.mu.Unlock()
+	if lg.initialized && strict {
+		return fmt.Errorf("%w as %q", ErrAlreadyInitialized, lg.module)
+	}
+	lg.module = module_name
+	lg.initialized = true
+	return nil
+}
+
+func (lg *Logger) Module() string {
+	lg.mu.RLock()
+	defer lg.mu.RUnlock()
+	return lg.module
+}
+
+//Returns a child logger for the same module whose messages also carry the given key-value pairs:
+//
+//	logger.With("user", id, "attempt", 2).Info("login failed")
+//
+//Keys that are not strings are formatted with fmt.Sprint. A key without a value is dropped with a warning.
+func (lg *Logger) With(keyvals ...interface{}) *Logger {
+	lg.mu.RLock()
+	child := &Logger{
+		module:      lg.module,
+		initialized: lg.initialized,
+		config:      lg.config,
+		file:        lg.file,
+		msgType:     lg.msgType,
+		ctx:         lg.ctx,
+		fields:      append(make([]Field, 0, len(lg.fields)+len(keyvals)/2), lg.fields...),
+	}
+	lg.mu.RUnlock()
+	child.fields = appendFields(child.fields, keyvals)
+	if len(keyvals)%2 != 0 {
+		lg.Warn(fmt.Sprintf("With called with an odd number of arguments, dropping key %v", keyvals[len(keyvals)-1]))
+	}
+	return child
+}
+
+//Returns a child logger whose messages carry the given type as the type key of JSON records, so
+//pipelines can tell events apart. Text lines do not show it. The child keeps the fields of lg.
+func (lg *Logger) WithType(t string) *Logger {
+	child := lg.With()
+	child.msgType = t
+	return child
+}
+
+//Appends the key-value pairs to fields, formatting keys that are not strings with fmt.Sprint.
+//A trailing key without a value is ignored.
+func appendFields(fields []Field, keyvals []interface{}) []Field {
+	for i := 0; i+1 < len(keyvals); i += 2 {
+		key, ok := keyvals[i].(string)
+		if !ok {
+			key = fmt.Sprint(keyvals[i])
+		}
+		fields = append(fields, Field{Key: key, Value: keyvals[i+1]})
+	}
+	return fields
+}
+
+func (lg *Logger) log(severity string, a ...interface{}) error {
+	if !lg.conf().GetEnabled() {
+		return nil
+	}
+	var l logMessage
+	l.createLogMessage(lg, severity, a...)
+	return l.printLogMessage()
+}
+
+func (lg *Logger) logf(severity string, format string, a ...interface{}) {
+	if !lg.conf().GetEnabled() {
+		return
+	}
+	var l logMessage
+	l.createLogMessage(lg, severity, fmt.Sprintf(format, a...))
+	l.printLogMessage()
+}
+
+func (lg *Logger) assert(cond bool, a ...interface{}) {
+	if cond || !lg.conf().GetEnabled() {
+		return
+	}
+	var l logMessage
+	l.createLogMessage(lg, lg.conf().GetAssertLevel().String(), a...)
+	_, l.File, l.Line, _ = runtime.Caller(2)
+	if l.Text == "" {
+		l.Text = "assertion failed"
+	} else {
+		l.Text = "assertion failed: " + l.Text
+	}
+	l.printLogMessage()
+}
+
+//Reports whether messages at level would be logged, so costly arguments can be skipped:
+//
+//	if logger.Enabled(orchid.DebugLevel) {
+//		logger.Debug(expensive())
+//	}
+func (lg *Logger) Enabled(level Level) bool {
+	c := lg.conf()
+	return c.GetEnabled() && level >= c.GetLevel()
+}
+
+func (lg *Logger) Info(a ...interface{}) {
+	lg.log("INFO", a...)
+}
+
+func (lg *Logger) OK(a ...interface{}) {
+	lg.log("OK", a...)
+}
+
+func (lg *Logger) Error(a ...interface{}) {
+	lg.log("ERROR", a...)
+}
+
+func (lg *Logger) Fatal(a ...interface{}) {
+	lg.log("FATAL", a...)
+}
+
+func (lg *Logger) Warn(a ...interface{}) {
+	lg.log("WARN", a...)
+}
+
+func (lg *Logger) Debug(a ...interface{}) {
+	lg.log("DEBUG", a...)
+}
+
+func (lg *Logger) Infof(format string, a ...interface{}) {
+	lg.logf("INFO", format, a...)
+}
+
+func (lg *Logger) OKf(format string, a ...interface{}) {
+	lg.logf("OK", format, a...)
+}
+
+func (lg *Logger) Errorf(format string, a ...interface{}) {
+	lg.logf("ERROR", format, a...)
+}
+
+func (lg *Logger) Fatalf(format string, a ...interface{}) {
+	lg.logf("FATAL", format, a...)
+}
+
+func (lg *Logger) Warnf(format string, a ...interface{}) {
+	lg.logf("WARN", format, a...)
+}
+
+func (lg *Logger) Debugf(format string, a ...interface{}) {
+	lg.logf("DEBUG", format, a...)
+}
+
+//Logs an assertion failure with the caller's location when cond is false. The level is set with SetAssertLevel.
+func (lg *Logger) Assert(cond bool, a ...interface{}) {
+	lg.assert(cond, a...)
+}
+
+//Logs a formatted message at the level set with SetPrintLevel, so a Logger can be passed
+//to libraries expecting a Printf-style logger
+func (lg *Logger) Printf(format string, a ...interface{}) {
+	if !lg.conf().GetEnabled() {
+		return
+	}
+	lg.log(lg.conf().GetPrintLevel().String(), strings.TrimSuffix(fmt.Sprintf(format, a...), "\n"))
+}
+
+//Logs a message at the level set with SetPrintLevel
+func (lg *Logger) Println(a ...interface{}) {
+	if !lg.conf().GetEnabled() {
+		return
+	}
+	lg.log(lg.conf().GetPrintLevel().String(), strings.TrimSuffix(fmt.Sprintln(a...), "\n"))
+}
+
+//Logs the message at the given level like Info or Error, and returns the error of writing it to the
+//file, output or syslog so the caller can react to it. Nil means it was written, filtered out, or
+//queued in async mode, where failures go to the error handler set with SetErrorHandler, or stderr.
+func (lg *Logger) LogE(level string, a ...interface{}) error {
+	if lv, err := ParseLevel(level); err == nil {
+		level = lv.String()
+	}
+	return lg.log(level, a...)
+}
+
+//Prints a horizontal rule across the console to set apart sections or test runs. It is not written to files.
+func (lg *Logger) Separator() {
+	lg.conf().Separator()
+}
+
+//Upper bound of the goroutine dump logged by StackAll
+const maxStackDump = 1 << 20
+
+//Returns the stack of the current goroutine, or of all goroutines up to maxStackDump bytes
+func stack(all bool) []byte {
+	buf := make([]byte, 4096)
+	for {
+		n := runtime.Stack(buf, all)
+		if n < len(buf) || len(buf) >= maxStackDump {
+			return buf[:n]
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+}
+
+func (lg *Logger) logStack(severity string, all bool, a ...interface{}) {
+	if !lg.conf().GetEnabled() {
+		return
+	}
+	if level, err := ParseLevel(severity); err == nil {
+		severity = level.String()
+	}
+	var l logMessage
+	l.createLogMessage(lg, severity, a...)
+	l.Detail = strings.TrimSuffix(string(stack(all)), "\n")
+	l.printLogMessage()
+}
+
+//Logs the message followed by the stack of the current goroutine at the given level
+func (lg *Logger) Stack(level string, a ...interface{}) {
+	lg.logStack(level, false, a...)
+}
+
+//Logs the message followed by the stacks of all goroutines at the given level
+func (lg *Logger) StackAll(level string, a ...interface{}) {
+	lg.logStack(level, true, a...)
+}
+
+//Returns the program counters of the innermost error in the chain of err with a pkg/errors style
+//StackTrace method, whose result is a slice of uintptr-based frames, or nil if there is none
+func errorStackPCs(err error) []uintptr {
+	var pcs []uintptr
+	for ; err != nil; err = errors.Unwrap(err) {
+		method := reflect.ValueOf(err).MethodByName("StackTrace")
+		if !method.IsValid() || method.Type().NumIn() != 0 || method.Type().NumOut() != 1 {
+			continue
+		}
+		trace := method.Call(nil)[0]
+		if trace.Kind() != reflect.Slice || trace.Type().Elem().Kind() != reflect.Uintptr {
+			continue
+		}
+		pcs = make([]uintptr, trace.Len())
+		for i := range pcs {
+			pcs[i] = uintptr(trace.Index(i).Uint())
+		}
+	}
+	return pcs
+}
+
+//Formats up to depth frames of pcs as "function (file:line)"
+func formatFrames(pcs []uintptr, depth int) []string {
+	if len(pcs) > depth {
+		pcs = pcs[:depth]
+	}
+	var lines []string
+	frames := runtime.CallersFrames(pcs)
+	for {
+		frame, more := frames.Next()
+		if frame.Function != "" || frame.File != "" {
+			lines = append(lines, fmt.Sprintf("%s (%s:%d)", frame.Function, frame.File, frame.Line))
+		}
+		if !more {
+			return lines
+		}
+	}
+}
+
+func (lg *Logger) errorStack(err error, a ...interface{}) {
+	c := lg.conf()
+	if !c.GetEnabled() {
+		return
+	}
+	if err != nil {
+		a = append(a, Err(err))
+	}
+	var l logMessage
+	l.createLogMessage(lg, "ERROR", a...)
+	depth := c.GetStackDepth()
+	pcs := errorStackPCs(err)
+	if pcs == nil {
+		pcs = make([]uintptr, depth)
+		pcs = pcs[:runtime.Callers(callerSkip, pcs)]
+	}
+	l.Stack = formatFrames(pcs, depth)
+	l.printLogMessage()
+}
+
+//Logs err at ERROR as an error field, with the message in a and a stack trace: the one carried by err
+//when it has a pkg/errors style StackTrace method, the stack of the caller otherwise. Text lines show
+//the frames on indented lines below the message, JSON records as a stack array. SetStackDepth limits
+//the number of frames.
+func (lg *Logger) ErrorStack(err error, a ...interface{}) {
+	lg.errorStack(err, a...)
+}
+
+func (lg *Logger) dump(prefix string, data []byte) {
+	c := lg.conf()
+	if !c.GetEnabled() || !c.enabled("DEBUG") {
+		return
+	}
+	var l logMessage
+	l.createLogMessage(lg, "DEBUG", fmt.Sprintf("%s (%d bytes)", prefix, len(data)))
+	shown := data
+	if limit := c.GetDumpLimit(); limit > 0 && len(data) > limit {
+		shown = data[:limit]
+	}
+	l.Detail = strings.TrimSuffix(hex.Dump(shown), "\n")
+	if len(shown) < len(data) {
+		l.Detail += fmt.Sprintf("\n... %d more bytes not shown", len(data)-len(shown))
+	}
+	l.printLogMessage()
+}
+
+//Logs data at DEBUG as a hexdump with the offset, hex bytes and ASCII of 16 bytes per line, for
+//protocol debugging. Data beyond the limit set with SetDumpLimit is left out with a note.
+func (lg *Logger) Dump(prefix string, data []byte) {
+	lg.dump(prefix, data)
+}
@@ -0,0 +1,314 @@
+// Package orchid
+//Copyright (c) 2022 Epiphyte LLC. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+// Author: Fernandez-Alcon, Jose
+// e-mail: jose@epiphyte.io
+package orchid
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+type printfLogger interface {
+	Printf(format string, a ...interface{})
+}
+
+func retryWithLogger(logger printfLogger) {
+	logger.Printf("retrying request %d of %d", 2, 3)
+}
+
+func TestLoggerPrintf(t *testing.T) {
+	config := GetConfiguration()
+	defer config.SetPrintLevel(config.GetPrintLevel())
+
+	logger := New("Retry")
+	out := captureConsole(t, func() { retryWithLogger(logger) })
+	if !strings.Contains(out, "Retry") || !strings.Contains(out, "INFO") || !strings.HasSuffix(out, "retrying request 2 of 3\n") {
+		t.Errorf("unexpected Printf output %q", out)
+	}
+
+	config.SetPrintLevel(WarnLevel)
+	out = captureConsole(t, func() { logger.Println("connection", "lost") })
+	if !strings.Contains(out, "WARN") || !strings.HasSuffix(out, "connection lost\n") {
+		t.Errorf("unexpected Println output %q", out)
+	}
+}
+
+func TestLoggerStack(t *testing.T) {
+	logger := New("Stack")
+	out := captureConsole(t, func() { logger.Stack("debug", "checkpoint") })
+	if !strings.Contains(out, "DEBUG") || !strings.Contains(out, "checkpoint\ngoroutine ") {
+		t.Errorf("expected the message followed by the stack, got %q", out)
+	}
+	if !strings.Contains(out, "orchid.TestLoggerStack") {
+		t.Errorf("expected the calling function in the stack, got %q", out)
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() { <-done }()
+	out = captureConsole(t, func() { logger.StackAll("INFO", "all goroutines") })
+	if strings.Count(out, "goroutine ") < 2 {
+		t.Errorf("expected several goroutines in the dump, got %q", out)
+	}
+}
+
+func TestLoggerWith(t *testing.T) {
+	logger := New("Fields")
+	output := captureOutput(t)
+
+	child := logger.With("user", "jose", "attempt", 2)
+	grandchild := child.With("reason", "bad password")
+	out := captureConsole(t, func() {
+		grandchild.Warn("login failed")
+		logger.Info("no fields")
+	})
+	want := `login failed user=jose attempt=2 reason="bad password"`
+	if !strings.Contains(out, want) || !strings.Contains(output.String(), want) {
+		t.Errorf("expected %q on the console and the output, got %q and %q", want, out, output.String())
+	}
+	if !strings.HasSuffix(out, "no fields\n") {
+		t.Errorf("the parent logger should not carry the child's fields, got %q", out)
+	}
+
+	out = captureConsole(t, func() { logger.With("orphan").Info("still logs") })
+	if !strings.Contains(out, "WARN") || !strings.Contains(out, "dropping key orphan") {
+		t.Errorf("expected a warning about the dangling key, got %q", out)
+	}
+	if !strings.HasSuffix(out, "still logs\n") {
+		t.Errorf("the message should be logged without the dangling key, got %q", out)
+	}
+}
+
+func TestStrictInit(t *testing.T) {
+	config := GetConfiguration()
+	defer config.SetStrictInit(false)
+
+	logger := &Logger{}
+	if err := logger.Init("First"); err != nil {
+		t.Fatalf("lenient Init failed: %v", err)
+	}
+	if err := logger.Init("Second"); err != nil || logger.Module() != "Second" {
+		t.Fatalf("lenient re-Init should rename the logger, got %q, %v", logger.Module(), err)
+	}
+
+	config.SetStrictInit(true)
+	if err := (&Logger{}).Init("Fresh"); err != nil {
+		t.Errorf("the first Init should succeed under strict mode, got %v", err)
+	}
+	if err := logger.Init("Third"); err == nil || !strings.Contains(err.Error(), `"Second"`) {
+		t.Errorf("expected an already initialized error, got %v", err)
+	}
+	if logger.Module() != "Second" {
+		t.Errorf("a rejected Init should not rename the logger, got %q", logger.Module())
+	}
+	if err := New("Named").Init("Renamed"); err == nil {
+		t.Errorf("a logger named by New should count as initialized")
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+func TestLogE(t *testing.T) {
+	config := GetConfiguration()
+	output := captureOutput(t)
+	devNull, nullErr := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if nullErr != nil {
+		t.Fatal(nullErr)
+	}
+	defer devNull.Close()
+	stderr := os.Stderr
+	defer func() { os.Stderr = stderr }()
+	os.Stderr = devNull
+	logger := New("TestLogE")
+
+	config.SetOutput(failingWriter{})
+	var err error
+	console := captureConsole(t, func() { err = logger.LogE("warn", "not written") })
+	if err == nil || err.Error() != "disk full" {
+		t.Errorf("expected the write error, got %v", err)
+	}
+	if !strings.Contains(console, "WARN") || !strings.Contains(console, "not written") {
+		t.Errorf("the console should still get the message, got %q", console)
+	}
+
+	config.SetOutput(output)
+	captureConsole(t, func() { err = LogE("INFO", "written") })
+	if err != nil || !strings.Contains(output.String(), "written") {
+		t.Errorf("expected the message to be written, got %v", err)
+	}
+}
+
+func TestLoggerInfof(t *testing.T) {
+	config := GetConfiguration()
+	defer config.SetIncludeCaller(config.GetIncludeCaller())
+	config.SetIncludeCaller(true)
+	Init("TestFramework")
+
+	console := captureConsole(t, func() {
+		New("TestInfof").Infof("count=%d", 3)
+		Warnf("%s of %d", "two", 3)
+	})
+	lines := strings.Split(strings.TrimSuffix(console, "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected two lines, got %q", console)
+	}
+	if !strings.Contains(lines[0], "count=3 (logger_test.go:") {
+		t.Errorf("expected the formatted text and the caller, got %q", lines[0])
+	}
+	if !strings.Contains(lines[1], "WARN") || !strings.Contains(lines[1], "two of 3 (logger_test.go:") {
+		t.Errorf("expected the formatted text and the caller, got %q", lines[1])
+	}
+}
+
+func TestSetModule(t *testing.T) {
+	config := GetConfiguration()
+	defer config.SetStrictInit(false)
+	config.SetStrictInit(true)
+
+	logger := New("Before").With("k", "v")
+	if err := logger.SetModule("After"); err != nil {
+		t.Fatalf("SetModule should rename under strict mode, got %v", err)
+	}
+	if logger.Module() != "After" {
+		t.Errorf("expected After, got %q", logger.Module())
+	}
+	for _, invalid := range []string{"", "  "} {
+		if err := logger.SetModule(invalid); err == nil {
+			t.Errorf("SetModule(%q) should fail", invalid)
+		}
+		if err := (&Logger{}).Init(invalid); err == nil {
+			t.Errorf("Init(%q) should fail", invalid)
+		}
+	}
+	if logger.Module() != "After" {
+		t.Errorf("a rejected name should not rename the logger, got %q", logger.Module())
+	}
+}
+
+func TestLoggerDump(t *testing.T) {
+	config := GetConfiguration()
+	defer config.SetDumpLimit(DefaultDumpLimit)
+	output := captureOutput(t)
+	logger := New("TestDump")
+	payload := []byte("GET / HTTP/1.1\r\nHost: x\r\n\r\n\x00\x01")
+
+	config.SetDumpLimit(20)
+	captureConsole(t, func() { logger.Dump("request", payload) })
+	out := output.String()
+	if !strings.Contains(out, "DEBUG  request (29 bytes)\n") {
+		t.Errorf("expected the prefix and size at DEBUG, got %q", out)
+	}
+	if !strings.Contains(out, "\n00000000  47 45 54 20 2f 20 48 54  54 50 2f 31 2e 31 0d 0a  |GET / HTTP/1.1..|\n") ||
+		!strings.Contains(out, "\n00000010  48 6f 73 74") {
+		t.Errorf("expected a canonical hexdump, got %q", out)
+	}
+	if !strings.HasSuffix(out, "\n... 9 more bytes not shown\n") {
+		t.Errorf("expected the truncation note, got %q", out)
+	}
+
+	output.Reset()
+	config.SetDumpLimit(0)
+	captureConsole(t, func() { Dump("full", payload) })
+	if strings.Contains(output.String(), "not shown") || !strings.Contains(output.String(), "|Host: x......|") {
+		t.Errorf("a zero limit should show everything, got %q", output.String())
+	}
+
+	output.Reset()
+	captureConsole(t, func() { logger.With("conn", 7).Dump("request", payload[:4]) })
+	if !strings.Contains(output.String(), "request (4 bytes) conn=7\n00000000  47 45 54 20") {
+		t.Errorf("fields should stay on the message line above the dump, got %q", output.String())
+	}
+}
+
+func TestLoggerEnabled(t *testing.T) {
+	config := GetConfiguration()
+	defer config.SetLevel(config.GetLevel())
+	logger := New("TestEnabled")
+
+	config.SetLevel(InfoLevel)
+	if logger.Enabled(DebugLevel) || Enabled(DebugLevel) {
+		t.Error("DEBUG should be disabled below the minimum level")
+	}
+	if !logger.Enabled(InfoLevel) || !Enabled(ErrorLevel) {
+		t.Error("levels at or above the minimum should be enabled")
+	}
+
+	config.SetEnabled(false)
+	defer config.SetEnabled(true)
+	if logger.Enabled(FatalLevel) {
+		t.Error("no level should be enabled while logging is turned off")
+	}
+}
+
+type testFrame uintptr
+type testStackTrace []testFrame
+
+//Mimics the errors of github.com/pkg/errors, which carry the stack of their creation
+type tracedError struct {
+	msg   string
+	stack []uintptr
+}
+
+func (e *tracedError) Error() string { return e.msg }
+
+func (e *tracedError) StackTrace() testStackTrace {
+	trace := make(testStackTrace, len(e.stack))
+	for i, pc := range e.stack {
+		trace[i] = testFrame(pc)
+	}
+	return trace
+}
+
+func newTracedError(msg string) error {
+	pcs := make([]uintptr, 32)
+	return &tracedError{msg: msg, stack: pcs[:runtime.Callers(1, pcs)]}
+}
+
+func TestErrorStack(t *testing.T) {
+	config := GetConfiguration()
+	defer config.SetStackDepth(DefaultStackDepth)
+	defer config.SetConsoleFormat(FormatText)
+	output := captureOutput(t)
+	logger := New("TestErrorStack")
+
+	captureConsole(t, func() { logger.ErrorStack(errors.New("plain"), "request failed") })
+	lines := strings.Split(strings.TrimSuffix(output.String(), "\n"), "\n")
+	if !strings.HasSuffix(lines[0], "ERROR  request failed error=plain") || len(lines) < 2 ||
+		!strings.HasPrefix(lines[1], "    github.com/epiphyte/orchid.TestErrorStack.func1 (") {
+		t.Errorf("expected the stack of the caller below the message, got %q", output.String())
+	}
+
+	output.Reset()
+	wrapped := fmt.Errorf("saving: %w", newTracedError("disk full"))
+	config.SetStackDepth(2)
+	captureConsole(t, func() { ErrorStack(wrapped) })
+	lines = strings.Split(strings.TrimSuffix(output.String(), "\n"), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[1], "    github.com/epiphyte/orchid.newTracedError (") {
+		t.Errorf("expected two frames from the error's own stack, got %q", output.String())
+	}
+
+	config.SetConsoleFormat(FormatJSON)
+	console := captureConsole(t, func() { logger.ErrorStack(wrapped, "json") })
+	var record struct {
+		Text  string   `json:"text"`
+		Stack []string `json:"stack"`
+	}
+	if err := json.Unmarshal([]byte(console), &record); err != nil {
+		t.Fatalf("invalid JSON %q: %v", console, err)
+	}
+	if record.Text != "json" || len(record.Stack) != 2 || !strings.Contains(record.Stack[0], "newTracedError") {
+		t.Errorf("expected the frames as a stack array, got %+v", record)
+	}
+}
@@ -0,0 +1,91 @@
+// Package orchid
+//Copyright (c) 2022 Epiphyte LLC. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+// Author: Fernandez-Alcon, Jose
+// e-mail: jose@epiphyte.io
+package orchid
+
+import (
+	"encoding/json"
+	"sync"
+)
+
+//Describes the ring of the most recent messages kept in memory
+type memoryBuffer struct {
+	mu   sync.Mutex
+	ring []logMessage //The kept messages, nil when disabled
+	next int          //Where the next message goes, the oldest once the ring is full
+	full bool         //Whether the ring wrapped around
+}
+
+//Keeps the last n messages that pass the minimum level in memory, overwriting the oldest,
+//so a panic handler can dump recent context:
+//
+//	defer func() {
+//		if r := recover(); r != nil {
+//			for _, line := range config.DumpBuffer() {
+//				fmt.Fprintln(os.Stderr, line)
+//			}
+//			panic(r)
+//		}
+//	}()
+//
+//Zero or less disables it and discards the kept messages.
+func (c *Configuration) SetMemoryBuffer(n int) {
+	m := &c.memory
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.ring, m.next, m.full = nil, 0, false
+	if n > 0 {
+		m.ring = make([]logMessage, n)
+	}
+}
+
+//Adds the message to the memory buffer, if enabled
+func (c *Configuration) remember(l *logMessage) {
+	m := &c.memory
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.ring == nil {
+		return
+	}
+	m.ring[m.next] = *l
+	m.next = (m.next + 1) % len(m.ring)
+	if m.next == 0 {
+		m.full = true
+	}
+}
+
+//Returns a copy of the kept messages, oldest first
+func (c *Configuration) memoryMessages() []logMessage {
+	m := &c.memory
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.full {
+		return append([]logMessage(nil), m.ring[:m.next]...)
+	}
+	return append(append([]logMessage(nil), m.ring[m.next:]...), m.ring[:m.next]...)
+}
+
+//Returns the messages kept by SetMemoryBuffer, oldest first, formatted like the lines of the log file
+func (c *Configuration) DumpBuffer() []string {
+	messages := c.memoryMessages()
+	lines := make([]string, len(messages))
+	for i := range messages {
+		lines[i] = messages[i].fileLine(messages[i].Time.Format(c.GetTimeFormat()))
+	}
+	return lines
+}
+
+//Returns the messages kept by SetMemoryBuffer, oldest first, as a JSON array of objects like the
+//lines of the JSON console format
+func (c *Configuration) DumpJSON() []byte {
+	messages := c.memoryMessages()
+	records := make([]jsonRecord, len(messages))
+	for i := range messages {
+		records[i] = messages[i].jsonRecord()
+	}
+	data, _ := json.Marshal(records)
+	return data
+}
@@ -0,0 +1,82 @@
+// Package orchid
+//Copyright (c) 2022 Epiphyte LLC. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+// Author: Fernandez-Alcon, Jose
+// e-mail: jose@epiphyte.io
+package orchid
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestMemoryBuffer(t *testing.T) {
+	config := GetConfiguration()
+	defer config.SetMemoryBuffer(0)
+	logger := New("TestMemory")
+
+	config.SetMemoryBuffer(3)
+	captureConsole(t, func() {
+		logger.Info("first")
+		logger.Info("second")
+	})
+	if lines := config.DumpBuffer(); len(lines) != 2 || !strings.HasSuffix(lines[0], "first") {
+		t.Fatalf("expected the two messages, got %q", lines)
+	}
+
+	captureConsole(t, func() {
+		for i := 0; i < 5; i++ {
+			logger.With("n", i).Warn("message ", i, Err(errors.New("boom")))
+		}
+	})
+	lines := config.DumpBuffer()
+	if len(lines) != 3 {
+		t.Fatalf("expected the ring to keep 3 messages, got %q", lines)
+	}
+	for i, line := range lines {
+		if want := fmt.Sprintf("message %d n=%d error=boom", i+2, i+2); !strings.HasSuffix(line, want) {
+			t.Errorf("line %d should end with %q, oldest first, got %q", i, want, line)
+		}
+	}
+
+	var records []map[string]interface{}
+	if err := json.Unmarshal(config.DumpJSON(), &records); err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 3 || records[0]["text"] != "message 2" || records[0]["severity"] != "WARN" {
+		t.Fatalf("unexpected JSON dump %v", records)
+	}
+	if records[2]["n"] != float64(4) || records[2]["error"] != "boom" {
+		t.Errorf("expected native numbers and error messages, got %v", records[2])
+	}
+}
+
+func TestMemoryBufferConcurrent(t *testing.T) {
+	config := GetConfiguration()
+	defer config.SetMemoryBuffer(0)
+	logger := New("TestMemoryConcurrent")
+
+	config.SetMemoryBuffer(16)
+	captureConsole(t, func() {
+		var wg sync.WaitGroup
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 100; j++ {
+					logger.Info("concurrent")
+					config.DumpBuffer()
+				}
+			}()
+		}
+		wg.Wait()
+	})
+	if n := len(config.DumpBuffer()); n != 16 {
+		t.Errorf("expected a full ring of 16, got %d", n)
+	}
+}
@@ -7,13 +7,16 @@
 package orchid
 
 import (
+	"errors"
 	"fmt"
-	"log"
+	"io"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
 	"time"
 )
 
-var module = "NO_NAME"
-
 const (
 	COLOR_RESET = "\033[0m"
 	COLOR_INFO  = "\033[48;5;33m"
@@ -22,88 +25,457 @@ const (
 	COLOR_ERROR = "\033[48;5;1m"
 	COLOR_FATAL = "\033[48;5;1m"
 	COLOR_DEBUG = "\033[48;5;5m"
+
+	CLEAR_LINE = "\r\033[2K"
 )
 
 //Describes the structure of a log message
 type logMessage struct {
-	Severity string    //The severity of the message [INFO, DEBUG, SUCCESS, WARNING, ERROR, FATAL]
-	Text     string    //The contents of the log
-	Module   string    //The name of the module where the log was originated
-	Time     time.Time // The time at which the log was created
+	Severity    string         //The severity of the message [INFO, DEBUG, SUCCESS, WARNING, ERROR, FATAL]
+	Text        string         //The contents of the log
+	Detail      string         //The multi-line block added by Stack, StackAll and Dump, shown below the text and never escaped
+	Module      string         //The name of the module where the log was originated
+	Time        time.Time      // The time at which the log was created
+	Func        string         //The function that created the log, when enabled
+	File        string         //The source file that created the log, when known
+	Line        int            //The line in File that created the log
+	ID          string         //Identifies the message uniquely, when enabled
+	Type        string         //The message type set with Logger.WithType, shown in JSON only
+	Stack       []string       //The frames added by ErrorStack, one "function (file:line)" per frame
+	Uptime      time.Duration  //Time elapsed since the logger started, when enabled
+	Fields      []Field        //Key-value pairs added with Logger.With
+	config      *Configuration //The configuration the message follows, the global one when nil
+	file        *loggerFile    //The file of the logger that created the message, if any
+	fromHandler bool           //Set on messages logged by the error handler, whose failures go to stderr
+	summary     bool           //Set on sampling summaries, which already went through the mirror, hooks and redactors
+}
+
+//Returns the configuration the message follows
+func (l *logMessage) conf() *Configuration {
+	if l.config != nil {
+		return l.config
+	}
+	return configuration
+}
+
+//The time the logger started, used for the uptime field
+var startTime = time.Now()
+
+//Escapes the line breaks of message texts under SetEscapeNewlines
+var newlineEscaper = strings.NewReplacer("\n", `\n`, "\r", `\r`)
+
+//Number of frames between createLogMessage and the user's call (createLogMessage <- Logger.log <- Info)
+const callerSkip = 3
+
+//Names the module of the package-level functions. Under SetStrictInit only the first call succeeds.
+func Init(module_name string) error {
+	return defaultLogger.Init(module_name)
 }
 
-func Init(module_name string) {
-	module = module_name
+func (l *logMessage) createLogMessage(lg *Logger, severity string, a ...interface{}) {
+	c := lg.conf()
+	var pc [1]uintptr
+	if c.GetIncludeCaller() || c.GetIncludeCallerFunc() {
+		runtime.Callers(callerSkip+1, pc[:])
+	}
+	text, fields := splitFields(a)
+	l.fill(lg, time.Now(), severity, text, pc[0])
+	if fields != nil {
+		l.Fields = append(append(make([]Field, 0, len(l.Fields)+len(fields)), l.Fields...), fields...)
+	}
 }
 
-func (l *logMessage) createLogMessage(severity string, a ...interface{}) {
-	l.Time = time.Now()
-	l.Text = fmt.Sprint(a...)
+//Sets up the message for lg. pc is the program counter of the logging call, or zero when unknown.
+func (l *logMessage) fill(lg *Logger, t time.Time, severity string, text string, pc uintptr) {
+	c := lg.conf()
+	l.config = c
+	l.Time = c.inZone(t)
+	l.fromHandler = c.inErrorHandler()
+	l.Text = text
 	l.Severity = severity
+	lg.mu.RLock()
+	l.Module = lg.module
+	l.Fields = lg.fields
+	l.file = lg.file
+	l.Type = lg.msgType
+	ctx := lg.ctx
+	lg.mu.RUnlock()
+	if ctx != nil {
+		l.Fields = c.contextFields(ctx, l.Fields)
+	}
+	if extra := append(c.buildFields(), c.originFields()...); len(extra) > 0 {
+		l.Fields = append(append(make([]Field, 0, len(l.Fields)+len(extra)), l.Fields...), extra...)
+	}
+	l.ID = c.nextRecordID()
+	if c.GetIncludeUptime() {
+		l.Uptime = l.Time.Sub(startTime)
+	}
+	if pc == 0 {
+		return
+	}
+	frame, _ := runtime.CallersFrames([]uintptr{pc}).Next()
+	if c.GetIncludeCaller() {
+		l.File, l.Line = frame.File, frame.Line
+	}
+	if c.GetIncludeCallerFunc() {
+		l.Func = frame.Function
+	}
+}
+
+//Describes where the message was created, as file:line and/or function name
+func (l *logMessage) caller() string {
+	var parts []string
+	if l.File != "" {
+		parts = append(parts, fmt.Sprintf("%s:%d", filepath.Base(l.File), l.Line))
+	}
+	if l.Func != "" {
+		parts = append(parts, l.Func)
+	}
+	return strings.Join(parts, " ")
+}
+
+//Renders a value for a key=value pair, quoting it when it would not parse back as a single token
+func fieldValue(value string) string {
+	if value == "" || strings.ContainsAny(value, " =\"\t\n\r") {
+		return strconv.Quote(value)
+	}
+	return value
+}
+
+//Returns the text followed by the detail block, as JSON records and subscribers see it
+func (l *logMessage) fullText() string {
+	if l.Detail == "" {
+		return l.Text
+	}
+	return l.Text + "\n" + l.Detail
+}
+
+//Returns the text with its line breaks escaped under SetEscapeNewlines, for the text renderers
+func (l *logMessage) escapedText() string {
+	if l.conf().GetEscapeNewlines() {
+		return newlineEscaper.Replace(l.Text)
+	}
+	return l.Text
+}
+
+//Returns the message text followed by the caller and key=value fields, then the optional detail block
+//and stack on the lines below
+func (l *logMessage) renderText() string {
+	text := l.escapedText()
+	if caller := l.caller(); caller != "" {
+		text += " (" + caller + ")"
+	}
+	for _, f := range l.Fields {
+		text += " " + f.Key + "=" + fieldValue(fmt.Sprint(f.Value))
+	}
+	if l.conf().GetModuleAsField() {
+		text += " module=" + fieldValue(l.Module)
+	}
+	if l.ID != "" {
+		text += " id=" + fieldValue(l.ID)
+	}
+	if l.Uptime > 0 {
+		text += " uptime_ms=" + strconv.FormatInt(l.Uptime.Milliseconds(), 10)
+	}
+	if l.Detail != "" {
+		text += "\n" + l.Detail
+	}
+	for _, frame := range l.Stack {
+		text += "\n    " + frame
+	}
+	return text
+}
+
+//Returns the text with the stack and the error chains of error fields moved to an indented block
+//below the first line, for reading ERROR and FATAL messages on the console
+func (l *logMessage) prettyText() string {
+	pretty := *l
+	pretty.Detail = ""
+	pretty.Fields = nil
+	pretty.Stack = nil
+	var block strings.Builder
+	for _, f := range l.Fields {
+		err, ok := f.Value.(error)
+		if !ok {
+			pretty.Fields = append(pretty.Fields, f)
+			continue
+		}
+		fmt.Fprintf(&block, "\n    %s: %v", f.Key, err)
+		for err = errors.Unwrap(err); err != nil; err = errors.Unwrap(err) {
+			fmt.Fprintf(&block, "\n      caused by: %v", err)
+		}
+	}
+	if l.Detail != "" {
+		for _, line := range strings.Split(l.Detail, "\n") {
+			block.WriteString("\n    " + line)
+		}
+	}
+	for _, frame := range l.Stack {
+		block.WriteString("\n    " + frame)
+	}
+	return pretty.renderText() + block.String()
+}
+
+//Returns the line written to files for the message
+func (l *logMessage) fileLine(timestamp string) string {
+	return fmt.Sprintf("%s %-20s %-6s %s", timestamp, l.Module, l.Severity, l.renderText())
+}
+
+//Writes the message as a plain text line to the file of its logger if it has one. Otherwise it goes to
+//the configured output or log file, to every file sink whose level it reaches and to syslog.
+//Returns the first error.
+func (l *logMessage) writeToFile() error {
+	c := l.conf()
+	if l.file != nil {
+		if written, err := l.file.write(l, c); written {
+			return err
+		}
+	}
+	output := c.GetOutput()
+	interval := c.GetRotationInterval()
+	c.outputMu.Lock()
+	defer c.outputMu.Unlock()
+	if output == nil && c.logFile == nil && len(c.fileSinks) == 0 && c.syslog == nil {
+		return nil
+	}
+	line := l.fileLine(c.timeCache.format(l.Time, c.GetTimeFormat()))
+	var jsonLine []byte
+	var first error
+	write := func(w io.Writer, format Format) {
+		text := line
+		if format == FormatJSON {
+			if jsonLine == nil {
+				jsonLine = l.jsonFileText()
+			}
+			text = string(jsonLine)
+		}
+		if _, err := fmt.Fprintln(w, text); err != nil && first == nil {
+			first = err
+		}
+	}
+	if output != nil {
+		write(output, FormatText)
+	} else if c.logFile != nil {
+		if err := c.rotate(c.logFile, l.Time, interval); err != nil {
+			return err
+		}
+		write(c.logFile.file, c.logFile.format)
+	}
+	level, levelErr := ParseLevel(l.Severity)
+	for _, sink := range c.fileSinks {
+		if levelErr == nil && level < sink.level {
+			continue
+		}
+		if err := c.rotate(sink, l.Time, interval); err != nil {
+			if first == nil {
+				first = err
+			}
+			continue
+		}
+		write(sink.file, sink.format)
+	}
+	if c.syslog != nil {
+		if err := c.syslog.write(l.Severity, l.Module+" "+l.renderText()); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
 }
 
-func (l *logMessage) printLogMessage() {
-	metadata := fmt.Sprintf("%-20s %-6s", module, l.Severity)
-	color := COLOR_INFO
-	switch l.Severity {
-	case "INFO":
-		color = COLOR_INFO
-		break
-	case "OK":
-		color = COLOR_OK
-		break
-	case "WARN":
-		color = COLOR_WARN
-		break
-	case "ERROR":
-		color = COLOR_ERROR
-		break
-	case "FATAL":
-		color = COLOR_FATAL
-		break
-	case "DEBUG":
-		color = COLOR_DEBUG
-		break
-	}
-	if l.Severity == "FATAL" {
-		log.Fatal(string(COLOR_RESET), string(color), metadata, string(COLOR_RESET), l.Text)
+//Returns the text console line without the log prefix, with the module and severity colored when target is
+//a terminal. The color starts right at the metadata and is reset before the single space preceding the text.
+func (l *logMessage) consoleLine(c *Configuration, target io.Writer) string {
+	metadata := fmt.Sprintf("%-*s %-*s", c.GetModuleWidth(), c.consoleModule(l.Module), c.GetSeverityWidth(), l.Severity)
+	text := l.renderText()
+	if c.GetPrettyErrors() && (l.Severity == "ERROR" || l.Severity == "FATAL") {
+		text = l.prettyText()
+	}
+	if c.useColors(target) {
+		metadata = c.consoleColor(l.Severity) + metadata + COLOR_RESET
+	}
+	return metadata + " " + text
+}
+
+//Prints the message on the console and writes it to the files, returning the error of the file write
+func (l *logMessage) printLogMessage() error {
+	c := l.conf()
+	if !l.prepare(c) {
+		return nil
+	}
+	c.groupMu.Lock()
+	printed, redraw, err := l.emit(c)
+	c.groupMu.Unlock()
+	return l.finish(c, printed, redraw, err)
+}
+
+//Runs the hooks, redactors and audit mirror on the message. Returns false if it must not be written.
+func (l *logMessage) prepare(c *Configuration) bool {
+	if !c.GetEnabled() {
+		return false
+	}
+	if l.summary {
+		return c.enabled(l.Severity) && c.adaptiveAllows(l)
+	}
+	c.mirror(l)
+	if !c.runHooks(l) {
+		return false
+	}
+	c.redact(l)
+	return c.enabled(l.Severity) && c.sample(l) && c.adaptiveAllows(l)
+}
+
+//Writes the message to the files and the console, without running any user code, so it can be called
+//under groupMu. Returns whether the console line was printed, the progress bar redraw to run once the
+//lock is released and the unreported file error.
+func (l *logMessage) emit(c *Configuration) (printed bool, redraw func(), err error) {
+	err = c.writeMessage(l)
+	if c.consoleMutes(l.Severity) {
+		return false, nil, err
+	}
+	writer, target := c.consoleFor(l.Severity)
+	redraw = c.getProgressRedraw()
+	if redraw != nil {
+		io.WriteString(writer, CLEAR_LINE)
+	}
+	if c.GetConsoleFormat() == FormatJSON {
+		writer.Write(append(l.jsonLine(), '\n'))
 	} else {
-		log.Println(string(COLOR_RESET), string(color), metadata, string(COLOR_RESET), l.Text)
+		c.consoleLogger(l.Severity).Println(l.consoleLine(c, target))
 	}
+	return true, redraw, err
+}
+
+//Reports the file error, counts the message, delivers it to subscribers, the memory buffer and the
+//webhook, and exits after FATAL once the pending webhook alerts are posted
+func (l *logMessage) finish(c *Configuration, printed bool, redraw func(), err error) error {
+	if err != nil {
+		c.reportWriteError(l, err)
+	}
+	c.stats.count(l.Severity)
+	c.publish(l)
+	c.remember(l)
+	c.alert(l)
+	if redraw != nil {
+		redraw()
+	}
+	if level, levelErr := ParseLevel(l.Severity); printed && levelErr == nil && level >= WarnLevel {
+		c.flushConsole()
+	}
+	if !l.summary && (l.Severity == "FATAL" || (l.Severity == "ERROR" && c.GetExitOnError())) {
+		c.Flush()
+		if err := c.drainWebhook(); err != nil {
+			c.reportError("ORCHID WEBHOOK ERROR:", err)
+		}
+		c.exit(1)
+	}
+	return err
+}
+
+//Reports whether the package-level functions would log messages at level
+func Enabled(level Level) bool {
+	return defaultLogger.Enabled(level)
 }
 
 func Info(a ...interface{}) {
-	var l logMessage
-	l.createLogMessage("INFO", a...)
-	l.printLogMessage()
+	defaultLogger.log("INFO", a...)
 }
 
 func OK(a ...interface{}) {
-	var l logMessage
-	l.createLogMessage("OK", a...)
-	l.printLogMessage()
+	defaultLogger.log("OK", a...)
 }
 
 func Error(a ...interface{}) {
-	var l logMessage
-	l.createLogMessage("ERROR", a...)
-	l.printLogMessage()
+	defaultLogger.log("ERROR", a...)
 }
 
 func Fatal(a ...interface{}) {
-	var l logMessage
-	l.createLogMessage("FATAL", a...)
-	l.printLogMessage()
+	defaultLogger.log("FATAL", a...)
 }
 
 func Warn(a ...interface{}) {
-	var l logMessage
-	l.createLogMessage("WARN", a...)
-	l.printLogMessage()
+	defaultLogger.log("WARN", a...)
 }
 
 func Debug(a ...interface{}) {
-	var l logMessage
-	l.createLogMessage("DEBUG", a...)
-	l.printLogMessage()
+	defaultLogger.log("DEBUG", a...)
+}
+
+func Infof(format string, a ...interface{}) {
+	defaultLogger.logf("INFO", format, a...)
+}
+
+func OKf(format string, a ...interface{}) {
+	defaultLogger.logf("OK", format, a...)
+}
+
+func Errorf(format string, a ...interface{}) {
+	defaultLogger.logf("ERROR", format, a...)
+}
+
+func Fatalf(format string, a ...interface{}) {
+	defaultLogger.logf("FATAL", format, a...)
+}
+
+func Warnf(format string, a ...interface{}) {
+	defaultLogger.logf("WARN", format, a...)
+}
+
+func Debugf(format string, a ...interface{}) {
+	defaultLogger.logf("DEBUG", format, a...)
+}
+
+//Returns a logger for the default module whose messages also carry the given key-value pairs
+func With(keyvals ...interface{}) *Logger {
+	return defaultLogger.With(keyvals...)
+}
+
+//Logs an assertion failure with the caller's location when cond is false. The level is set with SetAssertLevel.
+func Assert(cond bool, a ...interface{}) {
+	defaultLogger.assert(cond, a...)
+}
+
+//Logs a formatted message at the level set with SetPrintLevel
+func Printf(format string, a ...interface{}) {
+	if !configuration.GetEnabled() {
+		return
+	}
+	defaultLogger.log(configuration.GetPrintLevel().String(), strings.TrimSuffix(fmt.Sprintf(format, a...), "\n"))
+}
+
+//Logs a message at the level set with SetPrintLevel
+func Println(a ...interface{}) {
+	if !configuration.GetEnabled() {
+		return
+	}
+	defaultLogger.log(configuration.GetPrintLevel().String(), strings.TrimSuffix(fmt.Sprintln(a...), "\n"))
+}
+
+//Logs the message at the given level like Info or Error, and returns the error of writing it to the
+//file, output or syslog so the caller can react to it. Nil means it was written, filtered out, or
+//queued in async mode, where failures go to the error handler set with SetErrorHandler, or stderr.
+func LogE(level string, a ...interface{}) error {
+	if lv, err := ParseLevel(level); err == nil {
+		level = lv.String()
+	}
+	return defaultLogger.log(level, a...)
+}
+
+//Logs the message followed by the stack of the current goroutine at the given level
+func Stack(level string, a ...interface{}) {
+	defaultLogger.logStack(level, false, a...)
+}
+
+//Logs the message followed by the stacks of all goroutines at the given level
+func StackAll(level string, a ...interface{}) {
+	defaultLogger.logStack(level, true, a...)
+}
+
+//Logs err at ERROR with the message and the stack of err, or of the caller if err has none
+func ErrorStack(err error, a ...interface{}) {
+	defaultLogger.errorStack(err, a...)
+}
+
+//Logs data at DEBUG as a hexdump with the offset, hex bytes and ASCII of 16 bytes per line
+func Dump(prefix string, data []byte) {
+	defaultLogger.dump(prefix, data)
 }
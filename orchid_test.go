@@ -6,7 +6,20 @@
 // e-mail: jose@epiphyte.io
 package orchid
 
-import "testing"
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
 
 func TestINFO(t *testing.T) {
 	Init("TestFramework")
@@ -16,3 +29,624 @@ func TestINFO(t *testing.T) {
 	Warn("WARNING")
 	Debug("DEBUG")
 }
+
+//Runs fn and returns everything it printed to the console
+func captureConsole(t *testing.T, fn func()) string {
+	t.Helper()
+	var buf bytes.Buffer
+	writer, flags := log.Writer(), log.Flags()
+	log.SetOutput(&buf)
+	log.SetFlags(0)
+	defer func() {
+		log.SetOutput(writer)
+		log.SetFlags(flags)
+	}()
+	fn()
+	return buf.String()
+}
+
+func TestColorMode(t *testing.T) {
+	config := GetConfiguration()
+	defer config.SetColorMode(config.GetColorMode())
+	Init("TestFramework")
+
+	tests := []struct {
+		mode    ColorMode
+		colored bool
+	}{
+		{ColorAlways, true},
+		{ColorNever, false},
+		{ColorAuto, false}, //a bytes.Buffer is never a terminal
+	}
+	for _, tt := range tests {
+		config.SetColorMode(tt.mode)
+		out := captureConsole(t, func() { Info("colors") })
+		if strings.Contains(out, "\033[") != tt.colored {
+			t.Errorf("mode %d: expected colored=%v, got %q", tt.mode, tt.colored, out)
+		}
+		if !strings.Contains(out, "colors") {
+			t.Errorf("mode %d: message missing from %q", tt.mode, out)
+		}
+	}
+}
+
+func TestConsoleLayout(t *testing.T) {
+	config := GetConfiguration()
+	defer config.SetColorMode(config.GetColorMode())
+	Init("Layout")
+	defer Init("TestFramework")
+
+	config.SetColorMode(ColorNever)
+	if out := captureConsole(t, func() { Info("plain") }); out != "Layout               INFO   plain\n" {
+		t.Errorf("unexpected plain layout %q", out)
+	}
+	config.SetColorMode(ColorAlways)
+	if out := captureConsole(t, func() { Info("colored") }); out != COLOR_INFO+"Layout               INFO  "+COLOR_RESET+" colored\n" {
+		t.Errorf("unexpected colored layout %q", out)
+	}
+}
+
+func TestIncludeCallerFunc(t *testing.T) {
+	config := GetConfiguration()
+	defer config.SetIncludeCallerFunc(config.GetIncludeCallerFunc())
+	Init("TestFramework")
+
+	config.SetIncludeCallerFunc(true)
+	out := captureConsole(t, func() { Warn("caller") })
+	if !strings.Contains(out, "(github.com/epiphyte/orchid.TestIncludeCallerFunc.func1)") {
+		t.Errorf("expected the calling function in %q", out)
+	}
+
+	config.SetIncludeCallerFunc(false)
+	out = captureConsole(t, func() { Warn("caller") })
+	if strings.Contains(out, "TestIncludeCallerFunc") {
+		t.Errorf("caller function should be omitted, got %q", out)
+	}
+}
+
+func TestConsoleFlags(t *testing.T) {
+	config := GetConfiguration()
+	defer config.SetConsoleFlags(-1)
+	Init("TestFramework")
+
+	config.SetConsoleFlags(log.Ltime | log.Lmicroseconds)
+	out := captureConsole(t, func() { Info("flags") })
+	if !regexp.MustCompile(`^\d{2}:\d{2}:\d{2}\.\d{6} `).MatchString(out) {
+		t.Errorf("expected a microsecond time prefix, got %q", out)
+	}
+
+	config.SetConsoleFlags(-1)
+	out = captureConsole(t, func() { Info("flags") })
+	if regexp.MustCompile(`^\d`).MatchString(out) {
+		t.Errorf("expected the standard logger flags (none) to be inherited, got %q", out)
+	}
+}
+
+func TestShortModuleNames(t *testing.T) {
+	config := GetConfiguration()
+	defer config.SetShortModuleNames(false)
+	defer config.SetModuleSeparator(".")
+	Init("db.pool.conn")
+	defer Init("TestFramework")
+
+	config.SetShortModuleNames(true)
+	out := captureConsole(t, func() { Info("short") })
+	if strings.Contains(out, "db.pool") || !strings.Contains(out, "conn") {
+		t.Errorf("expected only the last module segment, got %q", out)
+	}
+
+	config.SetModuleSeparator("::")
+	Init("db::pool::conn")
+	out = captureConsole(t, func() { Info("short") })
+	if strings.Contains(out, "pool") || !strings.Contains(out, "conn") {
+		t.Errorf("expected the custom separator to be honored, got %q", out)
+	}
+
+	config.SetShortModuleNames(false)
+	out = captureConsole(t, func() { Info("short") })
+	if !strings.Contains(out, "db::pool::conn") {
+		t.Errorf("expected the full module name, got %q", out)
+	}
+}
+
+func TestConsoleWidths(t *testing.T) {
+	config := GetConfiguration()
+	defer config.SetModuleWidth(DefaultModuleWidth)
+	defer config.SetSeverityWidth(DefaultSeverityWidth)
+	Init("db")
+	defer Init("TestFramework")
+
+	out := captureConsole(t, func() { Info("padded") })
+	if !strings.Contains(out, "db                   INFO   padded") {
+		t.Errorf("expected the default padding, got %q", out)
+	}
+
+	config.SetModuleWidth(0)
+	config.SetSeverityWidth(0)
+	out = captureConsole(t, func() { Info("tight") })
+	if !strings.Contains(out, "db INFO tight") {
+		t.Errorf("expected no padding with zero widths, got %q", out)
+	}
+
+	config.SetModuleWidth(4)
+	config.SetSeverityWidth(8)
+	out = captureConsole(t, func() { Warn("wide") })
+	if !strings.Contains(out, "db   WARN     wide") {
+		t.Errorf("expected the configured widths, got %q", out)
+	}
+}
+
+func TestAssert(t *testing.T) {
+	Init("TestFramework")
+
+	out := captureConsole(t, func() { Assert(1+1 == 2, "math works") })
+	if out != "" {
+		t.Errorf("a passing assertion should not log, got %q", out)
+	}
+
+	out = captureConsole(t, func() { Assert(1+1 == 3, "math is broken") })
+	for _, want := range []string{"ERROR", "assertion failed: math is broken", "(orchid_test.go:"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in %q", want, out)
+		}
+	}
+}
+
+func TestModuleAsField(t *testing.T) {
+	config := GetConfiguration()
+	defer config.SetModuleAsField(false)
+	defer config.SetShortModuleNames(false)
+	Init("db.pool.conn")
+	defer Init("TestFramework")
+
+	out := captureConsole(t, func() { Info("field") })
+	if strings.Contains(out, "module=") {
+		t.Errorf("module field should be off by default, got %q", out)
+	}
+
+	config.SetModuleAsField(true)
+	config.SetShortModuleNames(true)
+	out = captureConsole(t, func() { Info("field") })
+	if !strings.HasSuffix(out, "field module=db.pool.conn\n") {
+		t.Errorf("expected the full module as a field, got %q", out)
+	}
+
+	Init("my module")
+	out = captureConsole(t, func() { Info("field") })
+	if !strings.Contains(out, `module="my module"`) {
+		t.Errorf("expected a quoted module field, got %q", out)
+	}
+}
+
+func TestConsoleMuteLevels(t *testing.T) {
+	config := GetConfiguration()
+	defer config.SetConsoleMuteLevels()
+	Init("TestFramework")
+
+	config.SetConsoleMuteLevels("debug", "INFO")
+	out := captureConsole(t, func() {
+		Debug("muted debug")
+		Info("muted info")
+		Warn("visible warn")
+	})
+	if strings.Contains(out, "muted") || !strings.Contains(out, "visible warn") {
+		t.Errorf("expected only the WARN line, got %q", out)
+	}
+
+	config.SetConsoleMuteLevels()
+	out = captureConsole(t, func() { Info("unmuted info") })
+	if !strings.Contains(out, "unmuted info") {
+		t.Errorf("expected INFO after unmuting, got %q", out)
+	}
+}
+
+func TestConsoleEnabled(t *testing.T) {
+	config := GetConfiguration()
+	defer config.SetConsoleEnabled(true)
+	Init("TestFramework")
+	output := captureOutput(t)
+	codes := stubExit(t)
+
+	config.SetConsoleEnabled(false)
+	out := captureConsole(t, func() {
+		Info("file only")
+		Separator()
+		Fatal("still exits")
+	})
+	if out != "" {
+		t.Errorf("nothing should be printed on the console, got %q", out)
+	}
+	if !strings.Contains(output.String(), "file only") || !strings.Contains(output.String(), "still exits") {
+		t.Errorf("the output should still get every message, got %q", output.String())
+	}
+	if len(*codes) != 1 || (*codes)[0] != 1 {
+		t.Errorf("FATAL should still exit with 1, got %v", *codes)
+	}
+
+	config.SetConsoleEnabled(true)
+	if out := captureConsole(t, func() { Info("console again") }); !strings.Contains(out, "console again") {
+		t.Errorf("expected the console back, got %q", out)
+	}
+}
+
+func TestEscapeNewlines(t *testing.T) {
+	config := GetConfiguration()
+	defer config.SetEscapeNewlines(true)
+	Init("TestFramework")
+	output := captureOutput(t)
+	forged := "user input\nERROR fake line\r"
+
+	out := captureConsole(t, func() { Info(forged) })
+	for name, got := range map[string]string{"console": out, "output": output.String()} {
+		if strings.Count(got, "\n") != 1 || !strings.HasSuffix(got, `user input\nERROR fake line\r`+"\n") {
+			t.Errorf("%s should escape the line breaks, got %q", name, got)
+		}
+	}
+
+	output.Reset()
+	captureConsole(t, func() { New("TestFramework").With("note", "a\rb").Info("carriage") })
+	if !strings.HasSuffix(output.String(), `carriage note="a\rb"`+"\n") {
+		t.Errorf("field values with a carriage return should be quoted, got %q", output.String())
+	}
+
+	jsonConfig := config.Clone()
+	jsonConfig.SetConsoleFormat(FormatJSON)
+	out = captureConsole(t, func() { jsonConfig.New("TestFramework").Info(forged) })
+	var record map[string]interface{}
+	if err := json.Unmarshal([]byte(out), &record); err != nil || record["text"] != forged {
+		t.Errorf("JSON records should keep the text as logged, got %q", out)
+	}
+
+	config.SetEscapeNewlines(false)
+	out = captureConsole(t, func() { Info(forged) })
+	if strings.Count(out, "\n") != 2 {
+		t.Errorf("line breaks should be kept when disabled, got %q", out)
+	}
+}
+
+//Replaces the exit function so FATAL paths can be tested, returning the recorded exit codes
+func stubExit(t *testing.T) *[]int {
+	t.Helper()
+	var codes []int
+	config := GetConfiguration()
+	config.SetExitFunc(func(code int) { codes = append(codes, code) })
+	t.Cleanup(func() { config.SetExitFunc(nil) })
+	return &codes
+}
+
+func TestExitOnError(t *testing.T) {
+	config := GetConfiguration()
+	defer config.SetExitOnError(false)
+	Init("TestFramework")
+	codes := stubExit(t)
+
+	captureConsole(t, func() { Error("not strict") })
+	if len(*codes) != 0 {
+		t.Fatalf("ERROR should not exit by default, got %v", *codes)
+	}
+
+	config.SetExitOnError(true)
+	out := captureConsole(t, func() {
+		Warn("still fine")
+		Error("strict")
+	})
+	if len(*codes) != 1 || (*codes)[0] != 1 {
+		t.Errorf("expected a single exit(1), got %v", *codes)
+	}
+	if !strings.Contains(out, "strict") {
+		t.Errorf("the ERROR should be written before exiting, got %q", out)
+	}
+
+}
+
+func TestExitFunc(t *testing.T) {
+	Init("TestFramework")
+	codes := stubExit(t)
+	output := captureOutput(t)
+
+	out := captureConsole(t, func() { Fatal("fatal") })
+	if len(*codes) != 1 || (*codes)[0] != 1 {
+		t.Errorf("FATAL should call the exit function with 1, got %v", *codes)
+	}
+	if !strings.Contains(out, "fatal") || !strings.Contains(output.String(), "fatal") {
+		t.Errorf("FATAL should be written everywhere before exiting, got %q and %q", out, output.String())
+	}
+}
+
+func TestRecordIDs(t *testing.T) {
+	config := GetConfiguration()
+	defer config.SetIncludeRecordID(false)
+	defer config.SetRecordIDGenerator(nil)
+	Init("TestFramework")
+
+	idPattern := regexp.MustCompile(` id=(\S+)\n`)
+	ids := func() []string {
+		out := captureConsole(t, func() {
+			for i := 0; i < 5; i++ {
+				Info("record ", i)
+			}
+		})
+		var found []string
+		for _, m := range idPattern.FindAllStringSubmatch(out, -1) {
+			found = append(found, m[1])
+		}
+		return found
+	}
+
+	if found := ids(); len(found) != 0 {
+		t.Fatalf("ids should be off by default, got %v", found)
+	}
+
+	config.SetIncludeRecordID(true)
+	seen := map[string]bool{}
+	for _, id := range ids() {
+		if seen[id] {
+			t.Errorf("duplicate id %s", id)
+		}
+		seen[id] = true
+	}
+	if len(seen) != 5 {
+		t.Errorf("expected 5 distinct ids, got %v", seen)
+	}
+
+	n := 0
+	config.SetRecordIDGenerator(func() string {
+		n++
+		return "custom-" + strconv.Itoa(n)
+	})
+	if found := ids(); len(found) != 5 || found[0] != "custom-1" || found[4] != "custom-5" {
+		t.Errorf("expected the custom generator ids, got %v", found)
+	}
+}
+
+func TestProgressRedraw(t *testing.T) {
+	config := GetConfiguration()
+	defer config.SetProgressRedraw(nil)
+	Init("TestFramework")
+
+	redraws := 0
+	config.SetProgressRedraw(func() {
+		redraws++
+		log.Writer().Write([]byte("[=====>    ]"))
+	})
+	out := captureConsole(t, func() { Info("progress") })
+	if !strings.HasPrefix(out, CLEAR_LINE) || !strings.HasSuffix(out, "progress\n[=====>    ]") {
+		t.Errorf("expected clear-line, log line and redraw, got %q", out)
+	}
+	if redraws != 1 {
+		t.Errorf("expected one redraw, got %d", redraws)
+	}
+
+	config.SetProgressRedraw(nil)
+	out = captureConsole(t, func() { Info("progress") })
+	if strings.Contains(out, CLEAR_LINE) {
+		t.Errorf("clear-line should only be written in progress mode, got %q", out)
+	}
+}
+
+func TestIncludeUptime(t *testing.T) {
+	config := GetConfiguration()
+	defer config.SetIncludeUptime(false)
+	Init("TestFramework")
+
+	uptimePattern := regexp.MustCompile(` uptime_ms=(\d+)\n`)
+	uptime := func() int {
+		out := captureConsole(t, func() { Info("boot step") })
+		m := uptimePattern.FindStringSubmatch(out)
+		if m == nil {
+			t.Fatalf("expected an uptime_ms field in %q", out)
+		}
+		ms, _ := strconv.Atoi(m[1])
+		return ms
+	}
+
+	config.SetIncludeUptime(true)
+	first := uptime()
+	time.Sleep(20 * time.Millisecond)
+	second := uptime()
+	if second-first < 20 {
+		t.Errorf("expected uptime to grow by at least 20ms, got %d then %d", first, second)
+	}
+
+	config.SetIncludeUptime(false)
+	out := captureConsole(t, func() { Info("boot step") })
+	if strings.Contains(out, "uptime_ms") {
+		t.Errorf("uptime should be off, got %q", out)
+	}
+}
+
+//Points the configured output at a buffer for the duration of the test
+func captureOutput(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	config := GetConfiguration()
+	previous := config.GetOutput()
+	config.SetOutput(&buf)
+	t.Cleanup(func() { config.SetOutput(previous) })
+	return &buf
+}
+
+func TestSetOutput(t *testing.T) {
+	config := GetConfiguration()
+	defer config.SetConsoleMuteLevels()
+	defer config.SetColorMode(config.GetColorMode())
+	Init("TestFramework")
+	output := captureOutput(t)
+
+	config.SetColorMode(ColorAlways)
+	config.SetConsoleMuteLevels("INFO")
+	console := captureConsole(t, func() {
+		Info("to the output")
+		Warn("everywhere")
+	})
+	if strings.Contains(console, "to the output") {
+		t.Errorf("muted INFO should not reach the console, got %q", console)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(output.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected two output lines, got %q", output.String())
+	}
+	line := regexp.MustCompile(`^\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2} TestFramework\s+INFO\s+to the output$`)
+	if !line.MatchString(lines[0]) {
+		t.Errorf("unexpected output line %q", lines[0])
+	}
+	if strings.Contains(output.String(), "\033[") {
+		t.Errorf("output should never be colored, got %q", output.String())
+	}
+
+	config.SetOutput(nil)
+	output.Reset()
+	captureConsole(t, func() { Info("console only") })
+	if output.Len() != 0 {
+		t.Errorf("nothing should be written after SetOutput(nil), got %q", output.String())
+	}
+}
+
+func TestConsoleTimestamp(t *testing.T) {
+	config := GetConfiguration()
+	defer config.SetConsoleFlags(-1)
+	defer config.SetConsoleTimestamp(true)
+	Init("TestFramework")
+	output := captureOutput(t)
+	timestamp := regexp.MustCompile(`\d{2}:\d{2}:\d{2}`)
+
+	config.SetConsoleFlags(log.LstdFlags)
+	out := captureConsole(t, func() { Info("stamped") })
+	if !timestamp.MatchString(out) {
+		t.Errorf("expected a console timestamp by default, got %q", out)
+	}
+
+	config.SetConsoleTimestamp(false)
+	out = captureConsole(t, func() { Info("unstamped") })
+	if timestamp.MatchString(out) {
+		t.Errorf("expected no console timestamp, got %q", out)
+	}
+	if !timestamp.MatchString(output.String()) || !strings.Contains(output.String(), "unstamped") {
+		t.Errorf("the output should keep its timestamp, got %q", output.String())
+	}
+}
+
+func TestIncludeCaller(t *testing.T) {
+	config := GetConfiguration()
+	defer config.SetIncludeCaller(false)
+	defer config.SetIncludeCallerFunc(false)
+	Init("TestFramework")
+	logger := New("TestCaller")
+
+	config.SetIncludeCaller(true)
+	var line int
+	out := captureConsole(t, func() {
+		_, _, line, _ = runtime.Caller(0)
+		Info("package level")
+		logger.Info("instance")
+	})
+	for i, want := range []string{
+		"package level (orchid_test.go:" + strconv.Itoa(line+1) + ")",
+		"instance (orchid_test.go:" + strconv.Itoa(line+2) + ")",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("call %d: expected %q in %q", i, want, out)
+		}
+	}
+
+	config.SetIncludeCallerFunc(true)
+	out = captureConsole(t, func() { logger.Info("both") })
+	if !regexp.MustCompile(`both \(orchid_test\.go:\d+ github\.com/epiphyte/orchid\.TestIncludeCaller\.func2\)`).MatchString(out) {
+		t.Errorf("expected file:line and function, got %q", out)
+	}
+}
+
+func TestPrettyErrors(t *testing.T) {
+	config := GetConfiguration()
+	defer config.SetPrettyErrors(false)
+	output := captureOutput(t)
+	logger := New("Pretty")
+
+	cause := errors.New("no such file")
+	err := fmt.Errorf("read config: %w", cause)
+	config.SetPrettyErrors(true)
+	out := captureConsole(t, func() {
+		logger.With("err", err, "path", "/etc/app.yaml").Error("startup failed")
+		logger.With("err", err).Warn("not an error level")
+	})
+	lines := strings.Split(out, "\n")
+	if len(lines) != 5 {
+		t.Fatalf("expected the ERROR block and a single WARN line, got %q", out)
+	}
+	if !strings.HasSuffix(lines[0], "startup failed path=/etc/app.yaml") {
+		t.Errorf("unexpected main line %q", lines[0])
+	}
+	if lines[1] != "    err: read config: no such file" || lines[2] != "      caused by: no such file" {
+		t.Errorf("unexpected error block %q", lines[1:3])
+	}
+	if !strings.HasSuffix(lines[3], `not an error level err="read config: no such file"`) {
+		t.Errorf("WARN should stay single-line, got %q", lines[3])
+	}
+
+	if strings.Count(output.String(), "\n") != 2 || !strings.Contains(output.String(), `startup failed err="read config: no such file" path=/etc/app.yaml`) {
+		t.Errorf("the output should keep single-line records, got %q", output.String())
+	}
+
+	out = captureConsole(t, func() { logger.Stack("ERROR", "with stack") })
+	if !strings.Contains(out, "with stack\n    goroutine ") {
+		t.Errorf("expected an indented stack block, got %q", out)
+	}
+}
+
+func TestUseUTC(t *testing.T) {
+	config := GetConfiguration()
+	defer config.SetUseUTC(false)
+	defer config.SetConsoleFlags(-1)
+	Init("TestFramework")
+	output := captureOutput(t)
+	records, unsubscribe := config.Subscribe()
+	defer unsubscribe()
+
+	config.SetUseUTC(true)
+	config.SetConsoleFlags(log.Ltime)
+	before := time.Now().UTC()
+	out := captureConsole(t, func() { Info("utc") })
+	after := time.Now().UTC()
+
+	r := <-records
+	if r.Time.Location() != time.UTC {
+		t.Errorf("expected a UTC record time, got %v", r.Time)
+	}
+	hours := func(s string) bool {
+		return strings.Contains(s, before.Format("15:04")) || strings.Contains(s, after.Format("15:04"))
+	}
+	if !hours(out) {
+		t.Errorf("expected a UTC console timestamp around %s, got %q", before.Format("15:04"), out)
+	}
+	if !hours(output.String()) {
+		t.Errorf("expected a UTC file timestamp around %s, got %q", before.Format("15:04"), output.String())
+	}
+}
+
+//Measures concurrent throughput of the package-level functions against a Logger of its own. Both only
+//share the configuration's RWMutex and the locks serializing writes to the output and the console.
+func benchmarkParallel(b *testing.B, info func(...interface{})) {
+	config := GetConfiguration()
+	previous := config.GetOutput()
+	config.SetOutput(io.Discard)
+	defer config.SetOutput(previous)
+	config.SetConsoleEnabled(false)
+	defer config.SetConsoleEnabled(true)
+	Init("Benchmark")
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			info("benchmarking concurrent logging")
+		}
+	})
+}
+
+func BenchmarkParallelPackage(b *testing.B) {
+	benchmarkParallel(b, Info)
+}
+
+func BenchmarkParallelLogger(b *testing.B) {
+	benchmarkParallel(b, New("Benchmark").Info)
+}
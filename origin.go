@@ -0,0 +1,42 @@
+// Package orchid
+//Copyright (c) 2022 Epiphyte LLC. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+// Author: Fernandez-Alcon, Jose
+// e-mail: jose@epiphyte.io
+package orchid
+
+import "os"
+
+//When enabled every message carries host and pid fields, in JSON and text, so records collected from many
+//machines can be traced to their origin. The hostname is read once here, not on every message.
+func (c *Configuration) SetIncludeHostPID(include bool) {
+	var host string
+	if include {
+		host, _ = os.Hostname()
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.includeHostPID = include
+	c.hostname = host
+}
+
+func (c *Configuration) GetIncludeHostPID() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.includeHostPID
+}
+
+//Returns the host and pid fields added to every message, if enabled. The host is left out when unknown.
+func (c *Configuration) originFields() []Field {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if !c.includeHostPID {
+		return nil
+	}
+	fields := make([]Field, 0, 2)
+	if c.hostname != "" {
+		fields = append(fields, Field{Key: "host", Value: c.hostname})
+	}
+	return append(fields, Field{Key: "pid", Value: os.Getpid()})
+}
@@ -0,0 +1,50 @@
+// Package orchid
+//Copyright (c) 2022 Epiphyte LLC. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+// Author: Fernandez-Alcon, Jose
+// e-mail: jose@epiphyte.io
+package orchid
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestSetIncludeHostPID(t *testing.T) {
+	config := GetConfiguration()
+	defer config.SetIncludeHostPID(false)
+	output := captureOutput(t)
+	logger := New("Origin")
+	host, err := os.Hostname()
+	if err != nil {
+		t.Skip("hostname unavailable:", err)
+	}
+	pid := fmt.Sprintf("pid=%d", os.Getpid())
+
+	out := captureConsole(t, func() { logger.Info("started") })
+	if strings.Contains(out, "pid=") {
+		t.Errorf("host and pid should be off by default, got %q", out)
+	}
+
+	config.SetIncludeHostPID(true)
+	out = captureConsole(t, func() { logger.With("user", "jose").Info("started") })
+	if !strings.HasSuffix(out, "started user=jose host="+host+" "+pid+"\n") {
+		t.Errorf("expected the host and pid fields, got %q", out)
+	}
+
+	defer config.SetConsoleFormat(FormatText)
+	config.SetConsoleFormat(FormatJSON)
+	output.Reset()
+	out = captureConsole(t, func() { logger.Info("started") })
+	var record map[string]interface{}
+	if err := json.Unmarshal([]byte(out), &record); err != nil {
+		t.Fatalf("invalid JSON %q: %v", out, err)
+	}
+	if record["host"] != host || record["pid"] != float64(os.Getpid()) {
+		t.Errorf("expected host and pid as JSON keys, got %v", record)
+	}
+}
@@ -0,0 +1,70 @@
+// Package orchid
+//Copyright (c) 2022 Epiphyte LLC. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+// Author: Fernandez-Alcon, Jose
+// e-mail: jose@epiphyte.io
+package orchid
+
+import (
+	"fmt"
+	"regexp"
+)
+
+//Describes a pattern replaced in every message before it is written
+type redactor struct {
+	pattern     *regexp.Regexp
+	replacement string //Expanded like regexp.ReplaceAllString, so $1 refers to a submatch
+}
+
+//Replaces the matches of pattern in the text and field values of every message before it reaches
+//the console, files, subscribers or the audit mirror, as a defense against logging secrets:
+//
+//	config.AddRedactor(regexp.MustCompile(`Bearer [A-Za-z0-9._-]+`), "Bearer [REDACTED]")
+//
+//Redactors run in the order they were added. Field values are formatted with fmt.Sprint first
+//and are only replaced when a redactor changes them.
+func (c *Configuration) AddRedactor(pattern *regexp.Regexp, replacement string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.redactors = append(c.redactors, redactor{pattern: pattern, replacement: replacement})
+}
+
+//Removes every redactor added with AddRedactor
+func (c *Configuration) ClearRedactors() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.redactors = nil
+}
+
+//Applies the redactors to the text and fields of the message
+func (c *Configuration) redact(l *logMessage) {
+	c.mu.RLock()
+	redactors := c.redactors
+	c.mu.RUnlock()
+	if len(redactors) == 0 {
+		return
+	}
+	apply := func(s string) string {
+		for _, r := range redactors {
+			s = r.pattern.ReplaceAllString(s, r.replacement)
+		}
+		return s
+	}
+	l.Text = apply(l.Text)
+	l.Detail = apply(l.Detail)
+	copied := false
+	for i, f := range l.Fields {
+		value := fmt.Sprint(f.Value)
+		redacted := apply(value)
+		if redacted == value {
+			continue
+		}
+		if !copied {
+			//The fields are shared with the logger they came from
+			l.Fields = append([]Field(nil), l.Fields...)
+			copied = true
+		}
+		l.Fields[i].Value = redacted
+	}
+}
@@ -0,0 +1,37 @@
+// Package orchid
+//Copyright (c) 2022 Epiphyte LLC. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+// Author: Fernandez-Alcon, Jose
+// e-mail: jose@epiphyte.io
+package orchid
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestRedactor(t *testing.T) {
+	config := GetConfiguration()
+	defer config.ClearRedactors()
+	output := captureOutput(t)
+
+	config.AddRedactor(regexp.MustCompile(`token=\w+`), "token=[REDACTED]")
+	config.AddRedactor(regexp.MustCompile(`\[REDACTED\]`), "***")
+	config.AddRedactor(regexp.MustCompile(`(\d{4})-\d{4}-\d{4}-(\d{4})`), "$1-****-****-$2")
+	logger := New("TestRedact").With("card", "4111-1111-1111-1234", "user", "alice")
+
+	console := captureConsole(t, func() { logger.Warn("retrying with token=abc123") })
+	for name, out := range map[string]string{"console": console, "output": output.String()} {
+		if strings.Contains(out, "abc123") || strings.Contains(out, "1111-1111") {
+			t.Errorf("%s leaked a secret: %q", name, out)
+		}
+		if !strings.Contains(out, "retrying with token=*** card=4111-****-****-1234 user=alice") {
+			t.Errorf("%s should have the redactors applied in order, got %q", name, out)
+		}
+	}
+	if logger.fields[0].Value != "4111-1111-1111-1234" {
+		t.Errorf("redaction should not change the logger fields, got %v", logger.fields[0].Value)
+	}
+}
@@ -0,0 +1,168 @@
+// Package orchid
+//Copyright (c) 2022 Epiphyte LLC. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+// Author: Fernandez-Alcon, Jose
+// e-mail: jose@epiphyte.io
+package orchid
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+//Number of tracked messages above which finished windows without suppressed messages are forgotten
+const maxSampledMessages = 4096
+
+//Identifies messages that are considered identical for sampling
+type sampleKey struct {
+	severity string
+	module   string
+	text     string
+}
+
+//Describes the current window of a sampled message
+type sampleWindow struct {
+	start      time.Time   //When the window started
+	count      int         //Occurrences in the window, including the first
+	suppressed int         //Occurrences in the window that were not logged and not yet summarized
+	first      logMessage  //The occurrence that started the window, repeated by the summary
+	timer      *time.Timer //Logs the summary when the window expires, set once a repeat is suppressed
+}
+
+//Describes the sampling of repeated messages
+type sampler struct {
+	mu      sync.Mutex
+	n       int           //Every nth occurrence in a window is logged, zero to log only the first
+	window  time.Duration //How long an occurrence suppresses its repeats, zero when disabled
+	windows map[sampleKey]*sampleWindow
+}
+
+//Reports whether the message should be logged. A window that expires after repeats were suppressed
+//is summarized by its timer, or right before the occurrence starting the next window if that comes first.
+func (c *Configuration) sample(l *logMessage) bool {
+	s := &c.sampler
+	s.mu.Lock()
+	if s.window <= 0 {
+		s.mu.Unlock()
+		return true
+	}
+	key := sampleKey{severity: l.Severity, module: l.Module, text: l.fullText()}
+	w := s.windows[key]
+	if w == nil || l.Time.Sub(w.start) >= s.window || l.Time.Before(w.start) {
+		var summary *logMessage
+		if w != nil {
+			summary = w.summarize()
+		} else {
+			s.forgetFinished(l.Time)
+		}
+		s.windows[key] = &sampleWindow{start: l.Time, count: 1, first: *l}
+		s.mu.Unlock()
+		if summary != nil {
+			c.logSummary(*summary, l.Time)
+		}
+		return true
+	}
+	defer s.mu.Unlock()
+	w.count++
+	if s.n > 0 && w.count%s.n == 0 {
+		return true
+	}
+	w.suppressed++
+	if w.timer == nil {
+		w.timer = time.AfterFunc(time.Until(w.start.Add(s.window)), func() { c.expireSample(key, w) })
+	}
+	return false
+}
+
+//Returns the summary of the suppressed repeats of the window, or nil when there are none, and starts counting anew
+func (w *sampleWindow) summarize() *logMessage {
+	if w.timer != nil {
+		w.timer.Stop()
+		w.timer = nil
+	}
+	if w.suppressed == 0 {
+		return nil
+	}
+	summary := w.first
+	summary.Text += fmt.Sprintf(" (suppressed %d similar messages)", w.suppressed)
+	summary.summary = true
+	w.suppressed = 0
+	return &summary
+}
+
+//Logs the summary of the window when its timer fires, unless it was already logged or the window replaced
+func (c *Configuration) expireSample(key sampleKey, w *sampleWindow) {
+	s := &c.sampler
+	s.mu.Lock()
+	var summary *logMessage
+	if s.windows[key] == w {
+		summary = w.summarize()
+	}
+	s.mu.Unlock()
+	if summary != nil {
+		c.logSummary(*summary, time.Now())
+	}
+}
+
+//Logs the summaries of every window with suppressed repeats, in the order the windows started, so
+//Flush and Close do not lose the counts
+func (c *Configuration) flushSamples() {
+	s := &c.sampler
+	s.mu.Lock()
+	var summaries []logMessage
+	for _, w := range s.windows {
+		if summary := w.summarize(); summary != nil {
+			summaries = append(summaries, *summary)
+		}
+	}
+	s.mu.Unlock()
+	sort.Slice(summaries, func(i, j int) bool { return summaries[i].Time.Before(summaries[j].Time) })
+	now := time.Now()
+	for _, summary := range summaries {
+		c.logSummary(summary, now)
+	}
+}
+
+//Logs a summary at t with a new record id
+func (c *Configuration) logSummary(l logMessage, t time.Time) {
+	if c.GetUseUTC() {
+		t = t.UTC()
+	}
+	l.Time = t
+	l.ID = c.nextRecordID()
+	l.printLogMessage()
+}
+
+//Bounds memory by dropping finished windows with nothing to report once too many messages are tracked
+func (s *sampler) forgetFinished(now time.Time) {
+	if len(s.windows) < maxSampledMessages {
+		return
+	}
+	for key, w := range s.windows {
+		if w.suppressed == 0 && now.Sub(w.start) >= s.window {
+			delete(s.windows, key)
+		}
+	}
+}
+
+//Logs identical messages, with the same severity, module and text, at most once per window plus every
+//nth occurrence within it. When a window with dropped messages expires, the message is logged once more
+//with "(suppressed N similar messages)" appended, even if it never recurs; Flush and Close log the
+//pending summaries right away. A window of zero disables sampling, the default.
+func (c *Configuration) SetSampling(n int, window time.Duration) {
+	s := &c.sampler
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.n, s.window = n, window
+	s.windows = make(map[sampleKey]*sampleWindow)
+}
+
+func (c *Configuration) GetSampling() (n int, window time.Duration) {
+	s := &c.sampler
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.n, s.window
+}
@@ -0,0 +1,108 @@
+// Package orchid
+//Copyright (c) 2022 Epiphyte LLC. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+// Author: Fernandez-Alcon, Jose
+// e-mail: jose@epiphyte.io
+package orchid
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSampling(t *testing.T) {
+	config := GetConfiguration()
+	defer config.SetSampling(0, 0)
+	output := captureOutput(t)
+	start := time.Now()
+	send := func(text string, at time.Duration) {
+		m := logMessage{Severity: "ERROR", Module: "Sampled", Text: text, Time: start.Add(at)}
+		m.printLogMessage()
+	}
+
+	config.SetSampling(3, time.Second)
+	captureConsole(t, func() {
+		for i := 0; i < 10; i++ {
+			send("connection refused", time.Duration(i)*time.Millisecond)
+		}
+		send("a different error", 20*time.Millisecond)
+		send("connection refused", 1500*time.Millisecond)
+	})
+
+	out := output.String()
+	//The first occurrence plus the 3rd, 6th and 9th, the summary, then the first of the next window
+	if n := strings.Count(out, "connection refused"); n != 6 {
+		t.Errorf("expected 6 logged repeats, got %d in %q", n, out)
+	}
+	if !strings.Contains(out, "a different error") {
+		t.Errorf("other messages should not be sampled, got %q", out)
+	}
+	if !strings.Contains(out, "connection refused (suppressed 6 similar messages)\n") {
+		t.Errorf("expected a summary of the suppressed count after the window, got %q", out)
+	}
+}
+
+func TestSamplingConcurrent(t *testing.T) {
+	config := GetConfiguration()
+	defer config.SetSampling(0, 0)
+	output := captureOutput(t)
+
+	config.SetSampling(0, time.Hour)
+	logger := New("SampledConcurrent")
+	captureConsole(t, func() {
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 100; j++ {
+					logger.Warn("same message")
+				}
+			}()
+		}
+		wg.Wait()
+	})
+	if n := strings.Count(output.String(), "same message"); n != 1 {
+		t.Errorf("expected a single message per window, got %d", n)
+	}
+}
+
+func TestSamplingSummaryWithoutRepeat(t *testing.T) {
+	config := GetConfiguration().Clone()
+	config.SetConsoleEnabled(false)
+	records, unsubscribe := config.Subscribe()
+	defer unsubscribe()
+	config.SetSampling(0, 50*time.Millisecond)
+	logger := config.New("Sampled")
+	for i := 0; i < 3; i++ {
+		logger.Error("disk full")
+	}
+	if r := <-records; r.Text != "disk full" {
+		t.Fatalf("expected the first occurrence, got %q", r.Text)
+	}
+	select {
+	case r := <-records:
+		if r.Text != "disk full (suppressed 2 similar messages)" || r.Severity != "ERROR" || r.Module != "Sampled" {
+			t.Errorf("unexpected summary %+v", r)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected a summary when the window expired")
+	}
+
+	config.SetSampling(0, time.Hour)
+	logger.Warn("slow query")
+	logger.Warn("slow query")
+	<-records
+	config.Flush()
+	select {
+	case r := <-records:
+		if r.Text != "slow query (suppressed 1 similar messages)" {
+			t.Errorf("unexpected summary %+v", r)
+		}
+	default:
+		t.Error("expected Flush to log the pending summary")
+	}
+}
@@ -0,0 +1,95 @@
+// Package orchid
+//Copyright (c) 2022 Epiphyte LLC. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+// Author: Fernandez-Alcon, Jose
+// e-mail: jose@epiphyte.io
+package orchid
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+//Describes a slog.Handler that prints slog records through an orchid Logger
+type slogHandler struct {
+	logger *Logger
+	attrs  []Field //Attributes added with WithAttrs, keys already qualified by their group
+	group  string  //Prefix of the keys of attributes added from now on, e.g. "request."
+}
+
+//Returns a slog.Handler that routes records through l, so slog.New(orchid.NewSlogHandler(l))
+//keeps orchid's console colors and file output. Attributes become structured fields, with
+//grouped keys joined by dots.
+func NewSlogHandler(l *Logger) slog.Handler {
+	return &slogHandler{logger: l}
+}
+
+//Returns the orchid severity matching a slog level
+func slogSeverity(level slog.Level) string {
+	switch {
+	case level < slog.LevelInfo:
+		return "DEBUG"
+	case level < slog.LevelWarn:
+		return "INFO"
+	case level < slog.LevelError:
+		return "WARN"
+	}
+	return "ERROR"
+}
+
+func (h *slogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return h.logger.conf().GetEnabled() && h.logger.conf().enabled(slogSeverity(level))
+}
+
+func (h *slogHandler) Handle(_ context.Context, r slog.Record) error {
+	t := r.Time
+	if t.IsZero() {
+		t = time.Now()
+	}
+	var l logMessage
+	l.fill(h.logger, t, slogSeverity(r.Level), r.Message, r.PC)
+	fields := make([]Field, 0, len(l.Fields)+len(h.attrs)+r.NumAttrs())
+	fields = append(append(fields, l.Fields...), h.attrs...)
+	r.Attrs(func(a slog.Attr) bool {
+		fields = appendAttr(fields, h.group, a)
+		return true
+	})
+	l.Fields = fields
+	return l.printLogMessage()
+}
+
+func (h *slogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	child := &slogHandler{logger: h.logger, group: h.group}
+	child.attrs = append([]Field{}, h.attrs...)
+	for _, a := range attrs {
+		child.attrs = appendAttr(child.attrs, h.group, a)
+	}
+	return child
+}
+
+func (h *slogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &slogHandler{logger: h.logger, attrs: h.attrs, group: h.group + name + "."}
+}
+
+//Appends the attribute as fields, flattening groups into dotted keys
+func appendAttr(fields []Field, prefix string, a slog.Attr) []Field {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return fields
+	}
+	if a.Value.Kind() == slog.KindGroup {
+		if a.Key != "" {
+			prefix += a.Key + "."
+		}
+		for _, ga := range a.Value.Group() {
+			fields = appendAttr(fields, prefix, ga)
+		}
+		return fields
+	}
+	return append(fields, Field{Key: prefix + a.Key, Value: a.Value.Any()})
+}
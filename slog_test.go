@@ -0,0 +1,56 @@
+// Package orchid
+//Copyright (c) 2022 Epiphyte LLC. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+// Author: Fernandez-Alcon, Jose
+// e-mail: jose@epiphyte.io
+package orchid
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestSlogHandler(t *testing.T) {
+	config := GetConfiguration()
+	defer config.SetLevel(config.GetLevel())
+	defer config.SetIncludeCaller(false)
+	output := captureOutput(t)
+
+	logger := slog.New(NewSlogHandler(New("Slog").With("service", "api")))
+	config.SetIncludeCaller(true)
+	captureConsole(t, func() {
+		logger.Debug("debugging")
+		logger.With("user", "jose").WithGroup("request").Info("handled", "status", 200, slog.Group("timing", "ms", 12))
+		logger.Warn("slow")
+		logger.Error("failed", "err", "timeout")
+	})
+
+	lines := strings.Split(strings.TrimSuffix(output.String(), "\n"), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("expected four lines, got %q", lines)
+	}
+	for i, want := range []string{
+		"DEBUG  debugging (slog_test.go:",
+		"INFO   handled (slog_test.go:",
+		"WARN   slow",
+		"ERROR  failed",
+	} {
+		if !strings.Contains(lines[i], want) {
+			t.Errorf("line %d: expected %q in %q", i, want, lines[i])
+		}
+	}
+	if !strings.HasSuffix(lines[1], "service=api user=jose request.status=200 request.timing.ms=12") {
+		t.Errorf("unexpected fields in %q", lines[1])
+	}
+	if !strings.HasSuffix(lines[3], "service=api err=timeout") {
+		t.Errorf("unexpected fields in %q", lines[3])
+	}
+
+	config.SetLevel(WarnLevel)
+	if logger.Enabled(context.Background(), slog.LevelInfo) || !logger.Enabled(context.Background(), slog.LevelWarn) {
+		t.Errorf("Enabled should follow the minimum level")
+	}
+}
@@ -0,0 +1,49 @@
+// Package orchid
+//Copyright (c) 2022 Epiphyte LLC. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+// Author: Fernandez-Alcon, Jose
+// e-mail: jose@epiphyte.io
+package orchid
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+//Counts the written messages per severity
+type stats struct {
+	counts sync.Map //Maps each severity to its *atomic.Uint64 counter
+}
+
+//Adds one message of severity to the counts
+func (s *stats) count(severity string) {
+	counter, ok := s.counts.Load(severity)
+	if !ok {
+		counter, _ = s.counts.LoadOrStore(severity, new(atomic.Uint64))
+	}
+	counter.(*atomic.Uint64).Add(1)
+}
+
+//Returns how many messages of each severity were written since the program started or ResetStats was
+//called, as a cheap way to watch error rates without parsing the files. Every level is present, zero
+//when unused. Messages dropped by the level, sampling or hooks are not counted.
+func (c *Configuration) Stats() map[string]uint64 {
+	counts := make(map[string]uint64, len(levelNames))
+	for _, name := range levelNames {
+		counts[name] = 0
+	}
+	c.stats.counts.Range(func(severity, counter interface{}) bool {
+		counts[severity.(string)] = counter.(*atomic.Uint64).Load()
+		return true
+	})
+	return counts
+}
+
+//Sets every count returned by Stats back to zero
+func (c *Configuration) ResetStats() {
+	c.stats.counts.Range(func(_, counter interface{}) bool {
+		counter.(*atomic.Uint64).Store(0)
+		return true
+	})
+}
@@ -0,0 +1,51 @@
+// Package orchid
+//Copyright (c) 2022 Epiphyte LLC. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+// Author: Fernandez-Alcon, Jose
+// e-mail: jose@epiphyte.io
+package orchid
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestStats(t *testing.T) {
+	config := GetConfiguration()
+	defer config.SetLevel(config.GetLevel())
+	captureOutput(t)
+	logger := New("TestStats")
+	config.ResetStats()
+
+	var wg sync.WaitGroup
+	captureConsole(t, func() {
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				logger.Info("info")
+				logger.Error("error")
+			}()
+		}
+		wg.Wait()
+		config.SetLevel(InfoLevel)
+		logger.Debug("filtered")
+		logger.LogE("TRACE", "custom")
+	})
+
+	stats := config.Stats()
+	if stats["INFO"] != 10 || stats["ERROR"] != 10 || stats["DEBUG"] != 0 || stats["TRACE"] != 1 {
+		t.Errorf("unexpected counts %v", stats)
+	}
+	if _, ok := stats["FATAL"]; !ok {
+		t.Errorf("every level should be present, got %v", stats)
+	}
+
+	config.ResetStats()
+	for severity, count := range config.Stats() {
+		if count != 0 {
+			t.Errorf("expected %s to be reset, got %d", severity, count)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+// Package orchid
+//Copyright (c) 2022 Epiphyte LLC. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+// Author: Fernandez-Alcon, Jose
+// e-mail: jose@epiphyte.io
+package orchid
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+//Describes the writer behind StdLogger, which logs every write as one message
+type stdWriter struct {
+	logger   *Logger
+	severity string
+}
+
+func (w *stdWriter) Write(p []byte) (int, error) {
+	if !w.logger.conf().GetEnabled() {
+		return len(p), nil
+	}
+	var pc uintptr
+	if w.logger.conf().GetIncludeCaller() || w.logger.conf().GetIncludeCallerFunc() {
+		pc = stdCaller()
+	}
+	var l logMessage
+	l.fill(w.logger, time.Now(), w.severity, strings.TrimSuffix(string(p), "\n"), pc)
+	l.printLogMessage()
+	return len(p), nil
+}
+
+//Returns the program counter of the first caller outside the log package, skipping Write
+func stdCaller() uintptr {
+	var pcs [8]uintptr
+	n := runtime.Callers(3, pcs[:])
+	for _, pc := range pcs[:n] {
+		frame, _ := runtime.CallersFrames([]uintptr{pc}).Next()
+		if !strings.HasPrefix(frame.Function, "log.") {
+			return pc
+		}
+	}
+	return 0
+}
+
+//Returns a standard library logger whose output is logged by lg at the given level, for libraries
+//that accept a *log.Logger such as http.Server.ErrorLog:
+//
+//	server := &http.Server{ErrorLog: logger.StdLogger("ERROR")}
+//
+//Each write becomes one message without its trailing newline.
+func (lg *Logger) StdLogger(level string) *log.Logger {
+	if lv, err := ParseLevel(level); err == nil {
+		level = lv.String()
+	}
+	return log.New(&stdWriter{logger: lg, severity: level}, "", 0)
+}
+
+//Returns a standard library logger whose output is logged at the given level by the default logger
+func StdLogger(level string) *log.Logger {
+	return defaultLogger.StdLogger(level)
+}
+
+//Describes the writer returned by NewWriter, which logs every complete line it receives
+type lineWriter struct {
+	mu       sync.Mutex
+	logger   *Logger
+	severity string
+	partial  []byte //The start of a line whose newline has not arrived yet
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	data := p
+	for {
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			break
+		}
+		line := data[:i]
+		if len(w.partial) > 0 {
+			line = append(w.partial, line...)
+			w.partial = w.partial[:0]
+		}
+		w.logLine(line)
+		data = data[i+1:]
+	}
+	w.partial = append(w.partial, data...)
+	return len(p), nil
+}
+
+//Logs the line without its trailing carriage return, as printed by programs writing CRLF
+func (w *lineWriter) logLine(line []byte) {
+	lg := w.logger
+	if !lg.conf().GetEnabled() {
+		return
+	}
+	var l logMessage
+	l.fill(lg, time.Now(), w.severity, string(bytes.TrimSuffix(line, []byte("\r"))), 0)
+	l.printLogMessage()
+}
+
+//Logs the buffered partial line, if any
+func (w *lineWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(w.partial) > 0 {
+		w.logLine(w.partial)
+		w.partial = nil
+	}
+	return nil
+}
+
+//Returns a writer that logs every line written to it as a message at the given level, e.g. to capture
+//the output of a subprocess:
+//
+//	stdout := logger.NewWriter("INFO")
+//	defer stdout.Close()
+//	cmd.Stdout = stdout
+//
+//A line is logged once its newline arrives. Close logs the remaining partial line.
+func (lg *Logger) NewWriter(level string) io.WriteCloser {
+	if lv, err := ParseLevel(level); err == nil {
+		level = lv.String()
+	}
+	return &lineWriter{logger: lg, severity: level}
+}
+
+//Returns a writer that logs every line written to it as a message of the default logger at the given level
+func NewWriter(level string) io.WriteCloser {
+	return defaultLogger.NewWriter(level)
+}
@@ -0,0 +1,62 @@
+// Package orchid
+//Copyright (c) 2022 Epiphyte LLC. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+// Author: Fernandez-Alcon, Jose
+// e-mail: jose@epiphyte.io
+package orchid
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestStdLogger(t *testing.T) {
+	config := GetConfiguration()
+	defer config.SetIncludeCaller(config.GetIncludeCaller())
+	config.SetIncludeCaller(true)
+	output := captureOutput(t)
+
+	std := New("TestStdLogger").StdLogger("error")
+	captureConsole(t, func() {
+		std.Printf("http: TLS handshake error from %s", "10.0.0.1")
+		std.Println("second line")
+	})
+
+	lines := strings.Split(strings.TrimSuffix(output.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected one message per write, got %q", output.String())
+	}
+	if !strings.Contains(lines[0], "TestStdLogger") || !strings.Contains(lines[0], "ERROR") ||
+		!strings.Contains(lines[0], "http: TLS handshake error from 10.0.0.1 (stdlog_test.go:") {
+		t.Errorf("unexpected line %q", lines[0])
+	}
+	if !strings.Contains(lines[1], "second line (stdlog_test.go:") {
+		t.Errorf("unexpected line %q", lines[1])
+	}
+}
+
+func TestNewWriter(t *testing.T) {
+	output := captureOutput(t)
+	w := New("TestWriter").NewWriter("warn")
+
+	captureConsole(t, func() {
+		io.WriteString(w, "first line\nsecond ")
+		io.WriteString(w, "line\r\nthird")
+		if strings.Contains(output.String(), "third") {
+			t.Error("a partial line should wait for its newline")
+		}
+		w.Close()
+	})
+
+	lines := strings.Split(strings.TrimSuffix(output.String(), "\n"), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected three messages, got %q", output.String())
+	}
+	for i, want := range []string{"first line", "second line", "third"} {
+		if !strings.Contains(lines[i], "WARN") || !strings.HasSuffix(lines[i], " "+want) {
+			t.Errorf("line %d should be a WARN ending with %q, got %q", i, want, lines[i])
+		}
+	}
+}
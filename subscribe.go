@@ -0,0 +1,143 @@
+// Package orchid
+//Copyright (c) 2022 Epiphyte LLC. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+// Author: Fernandez-Alcon, Jose
+// e-mail: jose@epiphyte.io
+package orchid
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+//Number of records a subscriber can fall behind before new records are dropped
+const subscriberBuffer = 256
+
+//Describes an emitted message as delivered to subscribers
+type Record struct {
+	Severity string                 //The severity of the message
+	Module   string                 //The name of the module where the log was originated
+	Text     string                 //The contents of the log
+	Time     time.Time              //The time at which the log was created
+	ID       string                 //The record id, when enabled
+	Fields   map[string]interface{} //The key-value pairs added with Logger.With
+}
+
+func (l *logMessage) record() Record {
+	r := Record{
+		Severity: l.Severity,
+		Module:   l.Module,
+		Text:     l.fullText(),
+		Time:     l.Time,
+		ID:       l.ID,
+		Fields:   make(map[string]interface{}, len(l.Fields)),
+	}
+	for _, f := range l.Fields {
+		r.Fields[f.Key] = f.Value
+	}
+	return r
+}
+
+//Describes the registered subscribers
+type subscribers struct {
+	mu       sync.RWMutex
+	next     int
+	chans    map[int]chan Record
+	captures []*capture //The CaptureOutput calls in progress, which never drop records
+	dropped  uint64     //Records not delivered because a subscriber's buffer was full
+}
+
+//Describes a CaptureOutput call in progress
+type capture struct {
+	mu      sync.Mutex
+	records []Record
+}
+
+//Returns a channel receiving a copy of every emitted record, in order, and a function that ends the
+//subscription and closes the channel. Records are dropped when the subscriber falls too far behind.
+func (c *Configuration) Subscribe() (<-chan Record, func()) {
+	s := &c.subscribers
+	ch := make(chan Record, subscriberBuffer)
+	s.mu.Lock()
+	if s.chans == nil {
+		s.chans = make(map[int]chan Record)
+	}
+	id := s.next
+	s.next++
+	s.chans[id] = ch
+	s.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			s.mu.Lock()
+			delete(s.chans, id)
+			s.mu.Unlock()
+			close(ch)
+		})
+	}
+}
+
+//Returns how many records were dropped because a subscriber was not keeping up
+func (c *Configuration) GetDroppedRecords() uint64 {
+	return atomic.LoadUint64(&c.subscribers.dropped)
+}
+
+//Runs fn and returns the records of the messages written while it ran, in order, so tests can check
+//the severity, module, text and fields of what they logged instead of matching console strings:
+//
+//	records := orchid.GetConfiguration().CaptureOutput(func() { handler.ServeHTTP(w, r) })
+//
+//The messages are still written as usual. Messages of other goroutines logged meanwhile are captured too,
+//while those of loggers following another configuration are not.
+func (c *Configuration) CaptureOutput(fn func()) []Record {
+	s := &c.subscribers
+	capt := &capture{}
+	s.mu.Lock()
+	s.captures = append(s.captures, capt)
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		for i, other := range s.captures {
+			if other == capt {
+				s.captures = append(s.captures[:i:i], s.captures[i+1:]...)
+				break
+			}
+		}
+	}()
+	fn()
+	capt.mu.Lock()
+	defer capt.mu.Unlock()
+	return capt.records
+}
+
+//Runs fn and returns the records of the messages written to the global configuration meanwhile
+func CaptureOutput(fn func()) []Record {
+	return configuration.CaptureOutput(fn)
+}
+
+//Delivers the message to every capture and, without blocking, to every subscriber
+func (c *Configuration) publish(l *logMessage) {
+	s := &c.subscribers
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if len(s.chans) == 0 && len(s.captures) == 0 {
+		return
+	}
+	r := l.record()
+	for _, capt := range s.captures {
+		capt.mu.Lock()
+		capt.records = append(capt.records, r)
+		capt.mu.Unlock()
+	}
+	for _, ch := range s.chans {
+		select {
+		case ch <- r:
+		default:
+			atomic.AddUint64(&s.dropped, 1)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+// Package orchid
+//Copyright (c) 2022 Epiphyte LLC. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+// Author: Fernandez-Alcon, Jose
+// e-mail: jose@epiphyte.io
+package orchid
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestSubscribe(t *testing.T) {
+	config := GetConfiguration()
+	records, unsubscribe := config.Subscribe()
+	logger := New("Subscriber").With("request", 7)
+
+	captureConsole(t, func() {
+		for i := 0; i < 3; i++ {
+			logger.Info("message ", i)
+		}
+		logger.Error("failure")
+	})
+	for i := 0; i < 3; i++ {
+		r := <-records
+		if r.Severity != "INFO" || r.Module != "Subscriber" || r.Text != "message "+strconv.Itoa(i) {
+			t.Errorf("unexpected record %d: %+v", i, r)
+		}
+		if r.Fields["request"] != 7 {
+			t.Errorf("expected the request field, got %v", r.Fields)
+		}
+	}
+	if r := <-records; r.Severity != "ERROR" || r.Text != "failure" {
+		t.Errorf("unexpected last record %+v", r)
+	}
+
+	dropped := config.GetDroppedRecords()
+	captureConsole(t, func() {
+		for i := 0; i < subscriberBuffer+10; i++ {
+			logger.Debug("flood")
+		}
+	})
+	if got := config.GetDroppedRecords() - dropped; got != 10 {
+		t.Errorf("expected 10 dropped records, got %d", got)
+	}
+
+	unsubscribe()
+	unsubscribe()
+	captureConsole(t, func() { logger.Info("after unsubscribe") })
+	n := 0
+	for range records {
+		n++
+	}
+	if n != subscriberBuffer {
+		t.Errorf("expected only the buffered records before the channel closed, got %d", n)
+	}
+}
+
+func TestCaptureOutput(t *testing.T) {
+	config := GetConfiguration()
+	defer config.SetLevel(config.GetLevel())
+	captureOutput(t)
+	logger := New("Captured").With("request", 7)
+
+	var inner []Record
+	var records []Record
+	captureConsole(t, func() {
+		records = CaptureOutput(func() {
+			for i := 0; i < subscriberBuffer+10; i++ {
+				logger.Info("message ", i)
+			}
+			inner = config.CaptureOutput(func() { logger.Warn("nested") })
+			config.SetLevel(ErrorLevel)
+			logger.Warn("filtered")
+		})
+		logger.Error("after")
+	})
+
+	if len(records) != subscriberBuffer+11 {
+		t.Fatalf("expected every written message without drops, got %d records", len(records))
+	}
+	if r := records[5]; r.Severity != "INFO" || r.Module != "Captured" || r.Text != "message 5" || r.Fields["request"] != 7 {
+		t.Errorf("unexpected record %+v", r)
+	}
+	if len(inner) != 1 || inner[0].Text != "nested" || records[len(records)-1].Text != "nested" {
+		t.Errorf("a nested capture should see its messages, as should the outer one, got %+v", inner)
+	}
+}
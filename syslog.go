@@ -0,0 +1,47 @@
+//go:build !windows && !plan9
+
+// Package orchid
+//Copyright (c) 2022 Epiphyte LLC. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+// Author: Fernandez-Alcon, Jose
+// e-mail: jose@epiphyte.io
+package orchid
+
+import (
+	"log/syslog"
+)
+
+//Describes a connection to a syslog daemon
+type syslogSink struct {
+	writer *syslog.Writer
+}
+
+func dialSyslog(network, addr, tag string) (*syslogSink, error) {
+	writer, err := syslog.Dial(network, addr, syslog.LOG_USER|syslog.LOG_INFO, tag)
+	if err != nil {
+		return nil, err
+	}
+	return &syslogSink{writer: writer}, nil
+}
+
+//Sends text with the syslog priority matching severity
+func (s *syslogSink) write(severity string, text string) error {
+	switch severity {
+	case "DEBUG":
+		return s.writer.Debug(text)
+	case "OK":
+		return s.writer.Notice(text)
+	case "WARN":
+		return s.writer.Warning(text)
+	case "ERROR":
+		return s.writer.Err(text)
+	case "FATAL":
+		return s.writer.Crit(text)
+	}
+	return s.writer.Info(text)
+}
+
+func (s *syslogSink) close() error {
+	return s.writer.Close()
+}
@@ -0,0 +1,28 @@
+//go:build windows || plan9
+
+// Package orchid
+//Copyright (c) 2022 Epiphyte LLC. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+// Author: Fernandez-Alcon, Jose
+// e-mail: jose@epiphyte.io
+package orchid
+
+import (
+	"errors"
+)
+
+//Describes a connection to a syslog daemon, which this platform does not have
+type syslogSink struct{}
+
+func dialSyslog(network, addr, tag string) (*syslogSink, error) {
+	return nil, errors.New("syslog is not supported on this platform")
+}
+
+func (s *syslogSink) write(severity string, text string) error {
+	return nil
+}
+
+func (s *syslogSink) close() error {
+	return nil
+}
@@ -0,0 +1,46 @@
+//go:build !windows && !plan9
+
+// Package orchid
+//Copyright (c) 2022 Epiphyte LLC. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+// Author: Fernandez-Alcon, Jose
+// e-mail: jose@epiphyte.io
+package orchid
+
+import (
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSetSyslog(t *testing.T) {
+	config := GetConfiguration()
+	defer config.Close()
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Skip("no UDP listener:", err)
+	}
+	defer conn.Close()
+
+	if err := config.SetSyslog("udp", conn.LocalAddr().String(), "orchidtest"); err != nil {
+		t.Fatal(err)
+	}
+	captureConsole(t, func() { New("TestSyslog").Error("disk full") })
+
+	buf := make([]byte, 1024)
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, _, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	//LOG_USER (8) | LOG_ERR (3)
+	packet := string(buf[:n])
+	if !strings.HasPrefix(packet, "<11>") || !strings.Contains(packet, "orchidtest") || !strings.Contains(packet, "TestSyslog disk full") {
+		t.Errorf("unexpected syslog packet %q", packet)
+	}
+	if err := config.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,218 @@
+// Package orchid
+//Copyright (c) 2022 Epiphyte LLC. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+// Author: Fernandez-Alcon, Jose
+// e-mail: jose@epiphyte.io
+package orchid
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//Describes the console colors used for each severity
+type Theme struct {
+	Colors map[string]string `json:"colors"` //ANSI escape sequence per severity, e.g. "INFO": "48;5;33"
+}
+
+func DefaultTheme() Theme {
+	return Theme{Colors: map[string]string{
+		"INFO":  COLOR_INFO,
+		"OK":    COLOR_OK,
+		"WARN":  COLOR_WARN,
+		"ERROR": COLOR_ERROR,
+		"FATAL": COLOR_FATAL,
+		"DEBUG": COLOR_DEBUG,
+	}}
+}
+
+var sgrParameters = regexp.MustCompile(`^[0-9]+(;[0-9]+)*$`)
+var sgrSequence = regexp.MustCompile(`^\x1b\[([0-9]+(;[0-9]+)*)m$`)
+
+//Returns the theme with every severity and escape sequence checked and normalized.
+//Colors may be full escape sequences or just their SGR parameters ("48;5;33").
+func (t Theme) validate() (Theme, error) {
+	normalized := Theme{Colors: make(map[string]string, len(t.Colors))}
+	for severity, code := range t.Colors {
+		level, err := ParseLevel(severity)
+		if err != nil {
+			return Theme{}, fmt.Errorf("theme: %w", err)
+		}
+		switch {
+		case sgrSequence.MatchString(code):
+		case sgrParameters.MatchString(code):
+			code = "\033[" + code + "m"
+		default:
+			return Theme{}, fmt.Errorf("theme: %w %q for %s", ErrInvalidColor, code, level)
+		}
+		normalized.Colors[level.String()] = code
+	}
+	return normalized, nil
+}
+
+func LoadThemeFromJSON(data []byte) (Theme, error) {
+	var theme Theme
+	if err := json.Unmarshal(data, &theme); err != nil {
+		return Theme{}, fmt.Errorf("theme: %w", err)
+	}
+	return theme.validate()
+}
+
+func LoadThemeFromFile(path string) (Theme, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Theme{}, err
+	}
+	return LoadThemeFromJSON(data)
+}
+
+//Sets the console colors. Severities missing from the theme keep their default color.
+func (c *Configuration) SetTheme(theme Theme) error {
+	theme, err := theme.validate()
+	if err != nil {
+		return err
+	}
+	colors := DefaultTheme().Colors
+	for severity, code := range theme.Colors {
+		colors[severity] = code
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.theme = Theme{Colors: colors}
+	return nil
+}
+
+func (c *Configuration) GetTheme() Theme {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	colors := make(map[string]string, len(c.theme.Colors))
+	for severity, code := range c.theme.Colors {
+		colors[severity] = code
+	}
+	return Theme{Colors: colors}
+}
+
+//Returns the escape sequence used to color the given severity
+func (c *Configuration) levelColor(severity string) string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if code, ok := c.theme.Colors[severity]; ok {
+		return code
+	}
+	return COLOR_INFO
+}
+
+//Describes how the theme colors are applied to the module and severity columns of the console
+type ColorStyle int
+
+const (
+	ColorBackground ColorStyle = iota //The theme colors as they are, backgrounds in the default theme
+	ColorForeground                   //Background colors are drawn as text colors instead, for light terminals
+	ColorBold                         //Like ColorForeground, in bold
+)
+
+//Sets how the theme colors are drawn. The default ColorBackground uses them unchanged, while ColorForeground
+//and ColorBold turn background colors (including 256-color and truecolor ones) into text colors.
+func (c *Configuration) SetColorStyle(style ColorStyle) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.style = style
+}
+
+func (c *Configuration) GetColorStyle() ColorStyle {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.style
+}
+
+//Sets a 24-bit truecolor for severity. It is stored as a background color, so SetColorStyle decides
+//whether it fills the background or colors the text.
+func (c *Configuration) SetLevelRGB(severity string, r, g, b uint8) error {
+	return c.SetLevelColor(severity, fmt.Sprintf("48;2;%d;%d;%d", r, g, b))
+}
+
+//Returns the escape sequence the console metadata of severity is drawn with, the theme color in the color style
+func (c *Configuration) consoleColor(severity string) string {
+	code := c.levelColor(severity)
+	style := c.GetColorStyle()
+	if style == ColorBackground {
+		return code
+	}
+	match := sgrSequence.FindStringSubmatch(code)
+	if match == nil {
+		return code
+	}
+	params := strings.Split(match[1], ";")
+	for i := 0; i < len(params); i++ {
+		n, _ := strconv.Atoi(params[i])
+		switch {
+		case n == 48 && i+1 < len(params) && (params[i+1] == "5" || params[i+1] == "2"):
+			params[i] = "38"
+			if params[i+1] == "5" {
+				i += 2
+			} else {
+				i += 4
+			}
+		case n >= 40 && n <= 47, n >= 100 && n <= 107:
+			params[i] = strconv.Itoa(n - 10)
+		case n == 49:
+			params[i] = "39"
+		}
+	}
+	if style == ColorBold {
+		params = append([]string{"1"}, params...)
+	}
+	return "\033[" + strings.Join(params, ";") + "m"
+}
+
+//Prints one sample line per level to w, so users can check how the active theme renders in their terminal
+func (c *Configuration) PrintColorLegend(w io.Writer) error {
+	levels := make([]Level, 0, len(levelNames))
+	for level := range levelNames {
+		levels = append(levels, level)
+	}
+	sort.Slice(levels, func(i, j int) bool { return levels[i] < levels[j] })
+	colored := c.useColors(w)
+	width := c.GetSeverityWidth()
+	for _, level := range levels {
+		name := level.String()
+		label := fmt.Sprintf("%-*s", width, name)
+		if colored {
+			label = c.consoleColor(name) + label + COLOR_RESET
+		}
+		if _, err := fmt.Fprintf(w, "%s %s messages look like this\n", label, name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//Overrides the console color of one severity. ansi is a full escape sequence or its SGR parameters ("38;5;82").
+func (c *Configuration) SetLevelColor(severity string, ansi string) error {
+	theme, err := Theme{Colors: map[string]string{severity: ansi}}.validate()
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for name, code := range theme.Colors {
+		c.theme.Colors[name] = code
+	}
+	return nil
+}
+
+//Returns the escape sequence used to color severity on the console
+func (c *Configuration) GetLevelColor(severity string) (string, error) {
+	level, err := ParseLevel(severity)
+	if err != nil {
+		return "", err
+	}
+	return c.levelColor(level.String()), nil
+}
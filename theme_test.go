@@ -0,0 +1,178 @@
+// Package orchid
+//Copyright (c) 2022 Epiphyte LLC. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+// Author: Fernandez-Alcon, Jose
+// e-mail: jose@epiphyte.io
+package orchid
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadTheme(t *testing.T) {
+	config := GetConfiguration()
+	defer config.SetTheme(DefaultTheme())
+	defer config.SetColorMode(config.GetColorMode())
+	Init("TestFramework")
+
+	path := filepath.Join(t.TempDir(), "theme.json")
+	data := `{"colors": {"info": "38;5;82", "ERROR": "\u001b[1;31m"}}`
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	theme, err := LoadThemeFromFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := config.SetTheme(theme); err != nil {
+		t.Fatal(err)
+	}
+
+	config.SetColorMode(ColorAlways)
+	out := captureConsole(t, func() {
+		Info("themed")
+		Error("themed")
+		Warn("default")
+	})
+	for _, want := range []string{"\033[38;5;82m", "\033[1;31m", COLOR_WARN} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected color %q in %q", want, out)
+		}
+	}
+	if strings.Contains(out, COLOR_INFO) {
+		t.Errorf("the default INFO color should be replaced, got %q", out)
+	}
+
+	for _, invalid := range []string{
+		`{"colors": {"INFO": "blue"}}`,
+		`{"colors": {"INFO": "\u001b[31"}}`,
+		`{"colors": {"TRACE": "31"}}`,
+		`{"colors": `,
+	} {
+		if _, err := LoadThemeFromJSON([]byte(invalid)); err == nil {
+			t.Errorf("expected an error loading %s", invalid)
+		}
+	}
+}
+
+func TestPrintColorLegend(t *testing.T) {
+	config := GetConfiguration()
+	defer config.SetTheme(DefaultTheme())
+	defer config.SetColorMode(config.GetColorMode())
+
+	if err := config.SetTheme(Theme{Colors: map[string]string{"OK": "42"}}); err != nil {
+		t.Fatal(err)
+	}
+	config.SetColorMode(ColorAlways)
+	var buf bytes.Buffer
+	if err := config.PrintColorLegend(&buf); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	expected := []struct{ level, color string }{
+		{"DEBUG", COLOR_DEBUG},
+		{"INFO", COLOR_INFO},
+		{"OK", "\033[42m"},
+		{"WARN", COLOR_WARN},
+		{"ERROR", COLOR_ERROR},
+		{"FATAL", COLOR_FATAL},
+	}
+	if len(lines) != len(expected) {
+		t.Fatalf("expected %d lines, got %q", len(expected), lines)
+	}
+	for i, e := range expected {
+		if !strings.HasPrefix(lines[i], e.color+e.level) {
+			t.Errorf("line %d: expected %s colored %q, got %q", i, e.level, e.color, lines[i])
+		}
+	}
+
+	config.SetColorMode(ColorNever)
+	buf.Reset()
+	config.PrintColorLegend(&buf)
+	if strings.Contains(buf.String(), "\033[") {
+		t.Errorf("legend should not be colored when colors are off, got %q", buf.String())
+	}
+}
+
+func TestSetLevelColor(t *testing.T) {
+	config := GetConfiguration()
+	defer config.SetTheme(DefaultTheme())
+	defer config.SetColorMode(config.GetColorMode())
+	Init("TestFramework")
+
+	if color, err := config.GetLevelColor("warn"); err != nil || color != COLOR_WARN {
+		t.Errorf("expected the default WARN color, got %q, %v", color, err)
+	}
+	if err := config.SetLevelColor("warn", "38;5;214"); err != nil {
+		t.Fatal(err)
+	}
+	if color, _ := config.GetLevelColor("WARN"); color != "\033[38;5;214m" {
+		t.Errorf("expected the custom WARN color, got %q", color)
+	}
+
+	config.SetColorMode(ColorAlways)
+	out := captureConsole(t, func() {
+		Warn("custom")
+		Error("default")
+	})
+	if !strings.Contains(out, "\033[38;5;214m") || !strings.Contains(out, COLOR_ERROR) {
+		t.Errorf("expected the custom WARN and default ERROR colors, got %q", out)
+	}
+
+	if err := config.SetLevelColor("TRACE", "31"); err == nil {
+		t.Errorf("expected an error for an unknown severity")
+	}
+	if _, err := config.GetLevelColor("TRACE"); err == nil {
+		t.Errorf("expected an error for an unknown severity")
+	}
+	if err := config.SetLevelColor("INFO", "red"); err == nil {
+		t.Errorf("expected an error for an invalid color")
+	}
+}
+
+func TestColorStyle(t *testing.T) {
+	config := GetConfiguration()
+	defer config.SetTheme(DefaultTheme())
+	defer config.SetColorStyle(ColorBackground)
+	defer config.SetColorMode(config.GetColorMode())
+	Init("TestFramework")
+	config.SetColorMode(ColorAlways)
+
+	if err := config.SetLevelRGB("ok", 0, 128, 255); err != nil {
+		t.Fatal(err)
+	}
+	if err := config.SetLevelColor("DEBUG", "1;45"); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		style ColorStyle
+		info  string
+		ok    string
+		debug string
+	}{
+		{ColorBackground, COLOR_INFO, "\033[48;2;0;128;255m", "\033[1;45m"},
+		{ColorForeground, "\033[38;5;33m", "\033[38;2;0;128;255m", "\033[1;35m"},
+		{ColorBold, "\033[1;38;5;33m", "\033[1;38;2;0;128;255m", "\033[1;1;35m"},
+	}
+	for _, test := range tests {
+		config.SetColorStyle(test.style)
+		out := captureConsole(t, func() {
+			Info("info")
+			OK("ok")
+			Debug("debug")
+		})
+		for _, want := range []string{test.info, test.ok, test.debug} {
+			if !strings.Contains(out, want) {
+				t.Errorf("style %d: expected %q in %q", test.style, want, out)
+			}
+		}
+	}
+	if color, _ := config.GetLevelColor("INFO"); color != COLOR_INFO {
+		t.Errorf("the style should not change the theme, got %q", color)
+	}
+}
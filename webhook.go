@@ -0,0 +1,190 @@
+// Package orchid
+//Copyright (c) 2022 Epiphyte LLC. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+// Author: Fernandez-Alcon, Jose
+// e-mail: jose@epiphyte.io
+package orchid
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	webhookQueueSize    = 128              //Alerts waiting to be posted before new ones are dropped
+	webhookCloseTimeout = 5 * time.Second  //How long Close and a FATAL exit wait for the pending alerts
+	webhookPostTimeout  = 10 * time.Second //How long a single POST may take
+)
+
+var webhookClient = &http.Client{Timeout: webhookPostTimeout}
+
+//Describes the webhook alerts are posted to
+type webhookSink struct {
+	url   string
+	level Level            //Messages below this level are not posted
+	queue chan webhookItem //Bodies waiting to be posted, in order
+	done  chan struct{}    //Closed when the goroutine exits
+}
+
+//Describes an entry of the webhook queue: a body to post, or a marker closed once everything before it was posted
+type webhookItem struct {
+	body    []byte
+	flushed chan struct{}
+}
+
+//Describes the webhook set with SetWebhook
+type webhook struct {
+	mu      sync.RWMutex //Held for reading while queueing, so the sink is not stopped meanwhile
+	sink    *webhookSink
+	dropped atomic.Uint64 //Alerts not queued because the queue was full
+}
+
+//Describes the JSON body posted to the webhook. text makes it a valid Slack incoming webhook message.
+type webhookPayload struct {
+	Text   string     `json:"text"`
+	Record jsonRecord `json:"record"`
+}
+
+//Posts every message at minLevel or above as JSON to rawURL, e.g. a Slack incoming webhook, for alerting
+//on incidents. Messages are posted in order from a background goroutine through a bounded queue, so logging
+//never waits on the network; when the queue is full new alerts are dropped and counted. Failed posts are
+//reported to the error handler. Close, and a FATAL message before exiting, wait a few seconds for the
+//pending alerts. An empty rawURL stops the webhook.
+func (c *Configuration) SetWebhook(rawURL string, minLevel Level) error {
+	var next *webhookSink
+	if rawURL != "" {
+		parsed, err := url.Parse(rawURL)
+		if err != nil {
+			return err
+		}
+		if (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return fmt.Errorf("invalid webhook URL %q", rawURL)
+		}
+		next = &webhookSink{
+			url:   rawURL,
+			level: minLevel,
+			queue: make(chan webhookItem, webhookQueueSize),
+			done:  make(chan struct{}),
+		}
+		go next.run(c)
+	}
+	c.webhook.mu.Lock()
+	previous := c.webhook.sink
+	c.webhook.sink = next
+	c.webhook.mu.Unlock()
+	if err := previous.stop(); err != nil {
+		return fmt.Errorf("webhook: %w", err)
+	}
+	return nil
+}
+
+//Returns how many alerts were dropped because the webhook queue was full
+func (c *Configuration) GetWebhookDropped() uint64 {
+	return c.webhook.dropped.Load()
+}
+
+//Queues the message for the webhook if it reaches the webhook level, without blocking
+func (c *Configuration) alert(l *logMessage) {
+	c.webhook.mu.RLock()
+	defer c.webhook.mu.RUnlock()
+	sink := c.webhook.sink
+	if sink == nil {
+		return
+	}
+	if level, err := ParseLevel(l.Severity); err != nil || level < sink.level {
+		return
+	}
+	body, _ := json.Marshal(webhookPayload{
+		Text:   l.Severity + " " + l.Module + ": " + l.renderText(),
+		Record: l.jsonRecord(),
+	})
+	select {
+	case sink.queue <- webhookItem{body: body}:
+	default:
+		c.webhook.dropped.Add(1)
+	}
+}
+
+//Stops and removes the webhook, waiting for the pending alerts
+func (c *Configuration) closeWebhook() error {
+	c.webhook.mu.Lock()
+	sink := c.webhook.sink
+	c.webhook.sink = nil
+	c.webhook.mu.Unlock()
+	return sink.stop()
+}
+
+//Waits until the alerts queued so far are posted, giving up after webhookCloseTimeout, so a FATAL alert
+//is sent before the program exits
+func (c *Configuration) drainWebhook() error {
+	timeout := time.NewTimer(webhookCloseTimeout)
+	defer timeout.Stop()
+	c.webhook.mu.RLock()
+	sink := c.webhook.sink
+	if sink == nil {
+		c.webhook.mu.RUnlock()
+		return nil
+	}
+	flushed := make(chan struct{})
+	select {
+	case sink.queue <- webhookItem{flushed: flushed}:
+		c.webhook.mu.RUnlock()
+	case <-timeout.C:
+		c.webhook.mu.RUnlock()
+		return fmt.Errorf("webhook: queue still full after %v", webhookCloseTimeout)
+	}
+	select {
+	case <-flushed:
+		return nil
+	case <-timeout.C:
+		return fmt.Errorf("webhook: alerts still pending after %v", webhookCloseTimeout)
+	}
+}
+
+func (w *webhookSink) run(c *Configuration) {
+	defer close(w.done)
+	for item := range w.queue {
+		if item.flushed != nil {
+			close(item.flushed)
+			continue
+		}
+		if err := w.post(item.body); err != nil {
+			c.reportError("ORCHID WEBHOOK ERROR:", err)
+		}
+	}
+}
+
+func (w *webhookSink) post(body []byte) error {
+	resp, err := webhookClient.Post(w.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded %s", resp.Status)
+	}
+	return nil
+}
+
+//Stops the goroutine once the queued alerts are posted, giving up after webhookCloseTimeout
+func (w *webhookSink) stop() error {
+	if w == nil {
+		return nil
+	}
+	close(w.queue)
+	select {
+	case <-w.done:
+		return nil
+	case <-time.After(webhookCloseTimeout):
+		return fmt.Errorf("%d alerts still pending after %v", len(w.queue), webhookCloseTimeout)
+	}
+}
@@ -0,0 +1,136 @@
+// Package orchid
+//Copyright (c) 2022 Epiphyte LLC. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+// Author: Fernandez-Alcon, Jose
+// e-mail: jose@epiphyte.io
+package orchid
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestWebhook(t *testing.T) {
+	var mu sync.Mutex
+	type payload struct {
+		Text   string                 `json:"text"`
+		Record map[string]interface{} `json:"record"`
+	}
+	var payloads []payload
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload payload
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Errorf("invalid webhook body: %v", err)
+		}
+		mu.Lock()
+		payloads = append(payloads, payload)
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	config := GetConfiguration().Clone()
+	if err := config.SetWebhook("ftp://example.com", ErrorLevel); err == nil {
+		t.Error("expected an error for a non-HTTP URL")
+	}
+	if err := config.SetWebhook(server.URL, ErrorLevel); err != nil {
+		t.Fatal(err)
+	}
+	logger := config.New("Alerts").With("order", 42)
+	captureConsole(t, func() {
+		logger.Warn("slow checkout")
+		logger.Error("payment failed")
+	})
+	if err := config.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(payloads) != 1 {
+		t.Fatalf("expected only the ERROR message to be posted before Close returned, got %+v", payloads)
+	}
+	p := payloads[0]
+	if p.Text != "ERROR Alerts: payment failed order=42" || p.Record["severity"] != "ERROR" || p.Record["order"] != float64(42) {
+		t.Errorf("unexpected payload %+v", p)
+	}
+}
+
+func TestWebhookOverflowAndErrors(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		<-release
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	config := GetConfiguration().Clone()
+	var mu sync.Mutex
+	var reported []error
+	config.SetErrorHandler(func(err error) {
+		mu.Lock()
+		reported = append(reported, err)
+		mu.Unlock()
+	})
+	if err := config.SetWebhook(server.URL, WarnLevel); err != nil {
+		t.Fatal(err)
+	}
+	logger := config.New("Alerts")
+	captureConsole(t, func() {
+		for i := 0; i < webhookQueueSize+10; i++ {
+			logger.Error("storm")
+		}
+	})
+	if config.GetWebhookDropped() == 0 {
+		t.Error("expected alerts to be dropped while the queue is full")
+	}
+	close(release)
+	if err := config.SetWebhook("", WarnLevel); err != nil {
+		t.Fatal(err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(reported) == 0 || !strings.Contains(reported[0].Error(), "500") {
+		t.Errorf("expected failed posts to reach the error handler, got %v", reported)
+	}
+}
+
+func TestWebhookFatal(t *testing.T) {
+	var mu sync.Mutex
+	var texts []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload struct {
+			Text string `json:"text"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Errorf("invalid webhook body: %v", err)
+		}
+		mu.Lock()
+		texts = append(texts, payload.Text)
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	config := GetConfiguration().Clone()
+	var posted []string
+	config.SetExitFunc(func(code int) {
+		mu.Lock()
+		posted = append([]string(nil), texts...)
+		mu.Unlock()
+	})
+	if err := config.SetWebhook(server.URL, ErrorLevel); err != nil {
+		t.Fatal(err)
+	}
+	defer config.Close()
+	captureConsole(t, func() { config.New("Alerts").Fatal("database unreachable") })
+	if len(posted) != 1 || posted[0] != "FATAL Alerts: database unreachable" {
+		t.Errorf("expected the FATAL alert to be posted before exiting, got %q", posted)
+	}
+}
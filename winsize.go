@@ -0,0 +1,42 @@
+//go:build linux || darwin || dragonfly || freebsd || netbsd || openbsd
+
+// Package orchid
+//Copyright (c) 2022 Epiphyte LLC. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+// Author: Fernandez-Alcon, Jose
+// e-mail: jose@epiphyte.io
+package orchid
+
+import (
+	"io"
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+//Describes the terminal size returned by the TIOCGWINSZ ioctl
+type winsize struct {
+	rows, cols, xpixels, ypixels uint16
+}
+
+//Returns the column count of the terminal w writes to, or zero when w is not a terminal or the size is unknown
+func terminalWidth(w io.Writer) int {
+	f, ok := w.(*os.File)
+	if !ok || !isTerminal(f) {
+		return 0
+	}
+	conn, err := f.SyscallConn()
+	if err != nil {
+		return 0
+	}
+	var size winsize
+	var errno syscall.Errno
+	conn.Control(func(fd uintptr) {
+		_, _, errno = syscall.Syscall(syscall.SYS_IOCTL, fd, syscall.TIOCGWINSZ, uintptr(unsafe.Pointer(&size)))
+	})
+	if errno != 0 {
+		return 0
+	}
+	return int(size.cols)
+}